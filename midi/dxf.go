@@ -0,0 +1,179 @@
+package midi
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// Layers of a laser cutting drawing
+const (
+	LayerCut     = "CUT"
+	LayerEngrave = "ENGRAVE"
+)
+
+// LaserOptions type used to configure a laser cutting drawing. Lengths are in
+// millimeters
+type LaserOptions struct {
+	// Kerf is the width of material the laser burns away. Cuts are moved by half
+	// of it so the holes and the strip come out at their drawn size
+	Kerf float64 `json:"kerf"`
+
+	// Labels engraves the name of each tine's note at the start of the strip
+	Labels bool `json:"labels"`
+}
+
+// DefaultLaserOptions suits a typical CO2 laser cutting paper or card
+var DefaultLaserOptions = LaserOptions{Kerf: 0.1, Labels: true}
+
+// CreateDXF function creates a DXF drawing of the paper strip for the MidiFile on
+// the given music box, ready for a laser cutter
+func CreateDXF(file MidiFile, spec MusicBoxSpec, options LaserOptions, outputPath string) error {
+	layout, err := NewLayout(&file, spec)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	if err := layout.WriteDXF(f, options); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// dxfWriter type used to write the group code and value pairs of a DXF file
+type dxfWriter struct {
+	*bufio.Writer
+	height float64 // Width of the strip, used to flip the y axis upwards
+}
+
+// pair writes a group code and its value
+func (w *dxfWriter) pair(code int, value string) {
+	w.WriteString(strconv.Itoa(code) + "\n" + value + "\n")
+}
+
+// number writes a group code and a length
+func (w *dxfWriter) number(code int, v float64) {
+	w.pair(code, strconv.FormatFloat(math.Round(v*10000)/10000, 'f', -1, 64))
+}
+
+// line writes a line between two points of the strip
+func (w *dxfWriter) line(layer string, x1, y1, x2, y2 float64) {
+	w.pair(0, "LINE")
+	w.pair(8, layer)
+	w.number(10, x1)
+	w.number(20, w.height-y1)
+	w.number(11, x2)
+	w.number(21, w.height-y2)
+}
+
+// rect writes the outline of a rectangle from its corners
+func (w *dxfWriter) rect(layer string, x1, y1, x2, y2 float64) {
+	w.line(layer, x1, y1, x2, y1)
+	w.line(layer, x2, y1, x2, y2)
+	w.line(layer, x2, y2, x1, y2)
+	w.line(layer, x1, y2, x1, y1)
+}
+
+// circle writes a circle from its center and radius
+func (w *dxfWriter) circle(layer string, x, y, r float64) {
+	w.pair(0, "CIRCLE")
+	w.pair(8, layer)
+	w.number(10, x)
+	w.number(20, w.height-y)
+	w.number(40, r)
+}
+
+// text writes a line of text centered vertically on the given point
+func (w *dxfWriter) text(layer string, x, y, size float64, s string) {
+	w.pair(0, "TEXT")
+	w.pair(8, layer)
+	w.number(10, x)
+	w.number(20, w.height-y-size/2)
+	w.number(40, size)
+	w.pair(1, s)
+}
+
+// WriteDXF draws the strip as an ASCII DXF drawing in millimeters. The outline
+// and holes are on the CUT layer, and the tine, beat and bar lines on the ENGRAVE
+// layer. Holes are cut as circles unless the music box uses square holes, since
+// crosses only mark where to punch by hand. The outline is moved out and the
+// holes in by half the kerf
+func (l Layout) WriteDXF(w io.Writer, o LaserOptions) error {
+	spec := l.Spec
+	if o.Kerf < 0 || o.Kerf >= spec.HoleDiameter {
+		return errors.New("midi: kerf cannot be negative and must be smaller than the hole diameter")
+	}
+
+	out := &dxfWriter{Writer: bufio.NewWriter(w), height: spec.Width}
+	offset := o.Kerf / 2
+
+	// The header sets the units to millimeters
+	out.pair(0, "SECTION")
+	out.pair(2, "HEADER")
+	out.pair(9, "$INSUNITS")
+	out.pair(70, "4")
+	out.pair(0, "ENDSEC")
+
+	// The layers are colored red for cutting and blue for engraving
+	out.pair(0, "SECTION")
+	out.pair(2, "TABLES")
+	out.pair(0, "TABLE")
+	out.pair(2, "LAYER")
+	out.pair(70, "2")
+	for _, layer := range []struct {
+		name  string
+		color string
+	}{{LayerCut, "1"}, {LayerEngrave, "5"}} {
+		out.pair(0, "LAYER")
+		out.pair(2, layer.name)
+		out.pair(70, "0")
+		out.pair(62, layer.color)
+		out.pair(6, "CONTINUOUS")
+	}
+	out.pair(0, "ENDTAB")
+	out.pair(0, "ENDSEC")
+
+	out.pair(0, "SECTION")
+	out.pair(2, "ENTITIES")
+
+	// Guide lines
+	first, last := spec.TineOffset(0), spec.TineOffset(len(spec.Notes)-1)
+	for i := range spec.Notes {
+		out.line(LayerEngrave, 0, spec.TineOffset(i), l.Length, spec.TineOffset(i))
+	}
+	for _, x := range l.Beats {
+		out.line(LayerEngrave, x, first, x, last)
+	}
+	if o.Labels {
+		for i, key := range spec.Notes {
+			out.text(LayerEngrave, 1, spec.TineOffset(i), spec.Pitch*0.6, midiToName[key])
+		}
+	}
+
+	// Outline and holes
+	out.rect(LayerCut, -offset, -offset, l.Length+offset, spec.Width+offset)
+
+	radius := spec.HoleDiameter/2 - offset
+	for _, hole := range l.Holes {
+		if spec.HoleShape == HoleSquare {
+			out.rect(LayerCut, hole.X-radius, hole.Y-radius, hole.X+radius, hole.Y+radius)
+		} else {
+			out.circle(LayerCut, hole.X, hole.Y, radius)
+		}
+	}
+
+	out.pair(0, "ENDSEC")
+	out.pair(0, "EOF")
+
+	return out.Flush()
+}
@@ -0,0 +1,146 @@
+package midi_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+// dxfEntity type used to hold the group codes of a DXF entity
+type dxfEntity map[string]string
+
+// dxfEntities returns the entities of a DXF drawing
+func dxfEntities(t *testing.T, data []byte) []dxfEntity {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines)%2 != 0 {
+		t.Fatal("DXF has an odd number of lines")
+	}
+
+	var entities []dxfEntity
+	inEntities := false
+	for i := 0; i < len(lines); i += 2 {
+		code, value := lines[i], lines[i+1]
+
+		switch {
+		case code == "2" && value == "ENTITIES":
+			inEntities = true
+		case code == "0" && value == "ENDSEC":
+			inEntities = false
+		case inEntities && code == "0":
+			entities = append(entities, dxfEntity{"0": value})
+		case inEntities && len(entities) > 0:
+			entities[len(entities)-1][code] = value
+		}
+	}
+
+	if lines[len(lines)-1] != "EOF" {
+		t.Error("DXF does not end with EOF")
+	}
+
+	return entities
+}
+
+func Test_WriteDXF(t *testing.T) {
+	f := layoutFile(t)
+	spec := midi.MusicBoxSpec{Notes: []byte{60, 62, 64}, Pitch: 3, Width: 12, Margin: 2, LeadIn: 10, LeadOut: 5, HoleDiameter: 2, MillimetersPerBeat: 8}
+
+	layout, err := midi.NewLayout(&f, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := layout.WriteDXF(&b, midi.LaserOptions{Kerf: 0.2, Labels: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for _, e := range dxfEntities(t, b.Bytes()) {
+		counts[e["8"]+" "+e["0"]]++
+
+		switch {
+		case e["0"] == "CIRCLE":
+			// Holes shrink by half the kerf, with y measured up from the bottom edge
+			if e["40"] != "0.9" || (e["20"] != "10" && e["20"] != "4") {
+				t.Errorf("hole at y %s with radius %s; want radius 0.9", e["20"], e["40"])
+			}
+		case e["8"] == midi.LayerCut:
+			// The outline grows by half the kerf
+			for _, code := range []string{"10", "11"} {
+				if e[code] != "-0.1" && e[code] != "31.1" {
+					t.Errorf("outline corner at x %s", e[code])
+				}
+			}
+		}
+	}
+
+	// Three tine lines, two beat lines and three labels are engraved
+	want := map[string]int{"CUT LINE": 4, "CUT CIRCLE": 2, "ENGRAVE LINE": 5, "ENGRAVE TEXT": 3}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Errorf("%d %s entities; want %d", counts[key], key, n)
+		}
+	}
+	if len(counts) != len(want) {
+		t.Errorf("entities = %v; want %v", counts, want)
+	}
+
+	// Square holes are cut as squares
+	spec.HoleShape = midi.HoleSquare
+	if layout, err = midi.NewLayout(&f, spec); err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err := layout.WriteDXF(&b, midi.LaserOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	counts = make(map[string]int)
+	for _, e := range dxfEntities(t, b.Bytes()) {
+		counts[e["8"]+" "+e["0"]]++
+	}
+	if counts["CUT LINE"] != 12 || counts["CUT CIRCLE"] != 0 || counts["ENGRAVE TEXT"] != 0 {
+		t.Errorf("entities = %v; want 12 cut lines and no circles or labels", counts)
+	}
+
+	// The kerf must leave something of the holes
+	for _, kerf := range []float64{-0.1, 2} {
+		if err := layout.WriteDXF(&bytes.Buffer{}, midi.LaserOptions{Kerf: kerf}); err == nil {
+			t.Errorf("WriteDXF() = nil with a kerf of %v; want an error", kerf)
+		}
+	}
+}
+
+func Test_CreateDXF(t *testing.T) {
+	var f midi.MidiFile
+	if _, err := f.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "strip.dxf")
+	if err := midi.CreateDXF(f, midi.MusicBox30, midi.DefaultLaserOptions, path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layout, err := midi.NewLayout(&f, midi.MusicBox30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	circles := 0
+	for _, e := range dxfEntities(t, data) {
+		if e["0"] == "CIRCLE" {
+			circles++
+		}
+	}
+	if circles != len(layout.Holes) {
+		t.Errorf("%d holes cut; want %d", circles, len(layout.Holes))
+	}
+}
@@ -0,0 +1,44 @@
+package midi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors that can be wrapped by a ParseError
+var (
+	ErrBadHeader      = errors.New("bad header chunk")
+	ErrBadTrackHeader = errors.New("bad track chunk")
+	ErrTruncated      = errors.New("truncated data")
+	ErrUnknownStatus  = errors.New("unknown status byte")
+	ErrBadVLQ         = errors.New("variable-length quantity longer than 4 bytes")
+)
+
+// ParseError type used to describe a problem found while parsing a MIDI file
+type ParseError struct {
+	Err    error  `json:"-"`
+	Msg    string `json:"msg"`
+	Offset int64  `json:"offset"`
+	Track  int    `json:"track"`
+}
+
+// Error returns a description of the error, including where in the file it occurred
+func (e *ParseError) Error() string {
+	s := "midi: " + e.Err.Error()
+	if e.Msg != "" {
+		s += ": " + e.Msg
+	}
+
+	s += " (offset " + fmt.Sprint(e.Offset)
+	if e.Track >= 0 {
+		s += ", track " + fmt.Sprint(e.Track)
+	}
+	s += ")"
+
+	return s
+}
+
+// Unwrap returns the underlying error so errors.Is can be used on a ParseError
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
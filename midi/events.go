@@ -0,0 +1,232 @@
+package midi
+
+import "fmt"
+
+// EventKind type used to identify the type of a channel event. Each kind has the
+// value of its status byte without the channel
+type EventKind byte
+
+const (
+	KindNoteOff         EventKind = VoiceNoteOff
+	KindNoteOn          EventKind = VoiceNoteOn
+	KindAftertouch      EventKind = VoiceAftertouch
+	KindControlChange   EventKind = VoiceControlChange
+	KindProgramChange   EventKind = VoiceProgramChange
+	KindChannelPressure EventKind = VoiceChannelPressure
+	KindPitchBend       EventKind = VoicePitchBend
+)
+
+// Names of the event kinds
+var kindNames = map[EventKind]string{
+	KindNoteOff:         "NoteOff",
+	KindNoteOn:          "NoteOn",
+	KindAftertouch:      "Aftertouch",
+	KindControlChange:   "ControlChange",
+	KindProgramChange:   "ProgramChange",
+	KindChannelPressure: "ChannelPressure",
+	KindPitchBend:       "PitchBend",
+}
+
+// String returns the name of the kind
+func (k EventKind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+
+	return "EventKind(" + fmt.Sprint(byte(k)) + ")"
+}
+
+// MarshalText encodes the kind as its name
+func (k EventKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a kind from its name
+func (k *EventKind) UnmarshalText(text []byte) error {
+	for kind, name := range kindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+
+	return fmt.Errorf("midi: unknown event kind %q", text)
+}
+
+// dataLength returns the number of data bytes following the status byte
+func (k EventKind) dataLength() int {
+	if k == KindProgramChange || k == KindChannelPressure {
+		return 1
+	}
+
+	return 2
+}
+
+// MidiEvent type used to hold a channel event from a track. The raw data bytes are
+// kept so the event can be written back unchanged; Message decodes them. A NoteOn
+// with a velocity of zero is stored as a NoteOff
+type MidiEvent struct {
+	Kind      EventKind `json:"kind"`
+	Channel   byte      `json:"channel"`
+	Data      [2]byte   `json:"data"`
+	DeltaTick int32     `json:"deltaTick"` // Ticks since the previous channel event of the track
+}
+
+// NewMidiEvent creates a MidiEvent holding the given message
+func NewMidiEvent(deltaTick int32, m Message) MidiEvent {
+	channel, data := m.encode()
+	return MidiEvent{Kind: m.Kind(), Channel: channel & 0x0F, Data: data, DeltaTick: deltaTick}
+}
+
+// Message decodes the event's data into a message of the matching concrete type
+func (e MidiEvent) Message() Message {
+	switch e.Kind {
+	case KindNoteOff:
+		return NoteOff{e.Channel, e.Data[0], e.Data[1]}
+	case KindNoteOn:
+		return NoteOn{e.Channel, e.Data[0], e.Data[1]}
+	case KindAftertouch:
+		return Aftertouch{e.Channel, e.Data[0], e.Data[1]}
+	case KindControlChange:
+		return ControlChange{e.Channel, e.Data[0], e.Data[1]}
+	case KindProgramChange:
+		return ProgramChange{e.Channel, e.Data[0]}
+	case KindChannelPressure:
+		return ChannelPressure{e.Channel, e.Data[0]}
+	case KindPitchBend:
+		return PitchBend{e.Channel, (int16(e.Data[1])<<7 | int16(e.Data[0])) - 8192}
+	}
+
+	return nil
+}
+
+// Status returns the status byte of the event
+func (e MidiEvent) Status() byte {
+	return byte(e.Kind) | e.Channel&0x0F
+}
+
+// Message is implemented by the concrete channel message types
+type Message interface {
+	Kind() EventKind
+
+	// encode returns the channel and raw data bytes of the message
+	encode() (byte, [2]byte)
+}
+
+// NoteOff type used to hold the data of a NoteOff message
+type NoteOff struct {
+	Channel  byte `json:"channel"`
+	Key      byte `json:"key"`
+	Velocity byte `json:"velocity"`
+}
+
+// NoteOn type used to hold the data of a NoteOn message
+type NoteOn struct {
+	Channel  byte `json:"channel"`
+	Key      byte `json:"key"`
+	Velocity byte `json:"velocity"`
+}
+
+// Aftertouch type used to hold the data of a polyphonic key pressure message
+type Aftertouch struct {
+	Channel  byte `json:"channel"`
+	Key      byte `json:"key"`
+	Pressure byte `json:"pressure"`
+}
+
+// ControlChange type used to hold the data of a control change message
+type ControlChange struct {
+	Channel    byte `json:"channel"`
+	Controller byte `json:"controller"`
+	Value      byte `json:"value"`
+}
+
+// ProgramChange type used to hold the data of a program change message
+type ProgramChange struct {
+	Channel byte `json:"channel"`
+	Program byte `json:"program"`
+}
+
+// ChannelPressure type used to hold the data of a channel pressure message
+type ChannelPressure struct {
+	Channel  byte `json:"channel"`
+	Pressure byte `json:"pressure"`
+}
+
+// PitchBend type used to hold the data of a pitch bend message. The value ranges
+// from -8192 to 8191, with 0 meaning no bend
+type PitchBend struct {
+	Channel byte  `json:"channel"`
+	Value   int16 `json:"value"`
+}
+
+// Kind returns KindNoteOff
+func (m NoteOff) Kind() EventKind {
+	return KindNoteOff
+}
+
+func (m NoteOff) encode() (byte, [2]byte) {
+	return m.Channel, [2]byte{m.Key & 0x7F, m.Velocity & 0x7F}
+}
+
+// Kind returns KindNoteOn
+func (m NoteOn) Kind() EventKind {
+	return KindNoteOn
+}
+
+func (m NoteOn) encode() (byte, [2]byte) {
+	return m.Channel, [2]byte{m.Key & 0x7F, m.Velocity & 0x7F}
+}
+
+// Kind returns KindAftertouch
+func (m Aftertouch) Kind() EventKind {
+	return KindAftertouch
+}
+
+func (m Aftertouch) encode() (byte, [2]byte) {
+	return m.Channel, [2]byte{m.Key & 0x7F, m.Pressure & 0x7F}
+}
+
+// Kind returns KindControlChange
+func (m ControlChange) Kind() EventKind {
+	return KindControlChange
+}
+
+func (m ControlChange) encode() (byte, [2]byte) {
+	return m.Channel, [2]byte{m.Controller & 0x7F, m.Value & 0x7F}
+}
+
+// Kind returns KindProgramChange
+func (m ProgramChange) Kind() EventKind {
+	return KindProgramChange
+}
+
+func (m ProgramChange) encode() (byte, [2]byte) {
+	return m.Channel, [2]byte{m.Program & 0x7F, 0}
+}
+
+// Kind returns KindChannelPressure
+func (m ChannelPressure) Kind() EventKind {
+	return KindChannelPressure
+}
+
+func (m ChannelPressure) encode() (byte, [2]byte) {
+	return m.Channel, [2]byte{m.Pressure & 0x7F, 0}
+}
+
+// Kind returns KindPitchBend
+func (m PitchBend) Kind() EventKind {
+	return KindPitchBend
+}
+
+func (m PitchBend) encode() (byte, [2]byte) {
+	// Clamp the value to the 14 bit range and split it into two 7 bit bytes
+	v := int(m.Value) + 8192
+	if v < 0 {
+		v = 0
+	} else if v > 0x3FFF {
+		v = 0x3FFF
+	}
+
+	return m.Channel, [2]byte{byte(v & 0x7F), byte(v >> 7)}
+}
@@ -0,0 +1,92 @@
+package midi_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_EventMessages(t *testing.T) {
+	track := "\x00\x93\x3c\x64" +
+		"\x00\x93\x3c\x00" +
+		"\x00\x83\x3d\x20" +
+		"\x00\xa3\x3c\x11" +
+		"\x00\xb3\x40\x7f" +
+		"\x00\xc3\x13" +
+		"\x00\xd3\x22" +
+		"\x00\xe3\x00\x00" +
+		"\x00\xe3\x00\x40" +
+		"\x00\xe3\x7f\x7f"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(0, 96, track)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []midi.Message{
+		midi.NoteOn{Channel: 3, Key: 0x3c, Velocity: 0x64},
+		midi.NoteOff{Channel: 3, Key: 0x3c, Velocity: 0},
+		midi.NoteOff{Channel: 3, Key: 0x3d, Velocity: 0x20},
+		midi.Aftertouch{Channel: 3, Key: 0x3c, Pressure: 0x11},
+		midi.ControlChange{Channel: 3, Controller: 0x40, Value: 0x7f},
+		midi.ProgramChange{Channel: 3, Program: 0x13},
+		midi.ChannelPressure{Channel: 3, Pressure: 0x22},
+		midi.PitchBend{Channel: 3, Value: -8192},
+		midi.PitchBend{Channel: 3, Value: 0},
+		midi.PitchBend{Channel: 3, Value: 8191},
+	}
+
+	events := f.Tracks[0].Events
+	if len(events) != len(want) {
+		t.Fatalf("parsed %d events; want %d", len(events), len(want))
+	}
+	for i, event := range events {
+		m := event.Message()
+		if !reflect.DeepEqual(m, want[i]) {
+			t.Errorf("event %d message = %#v; want %#v", i, m, want[i])
+		}
+		if event.Kind != want[i].Kind() {
+			t.Errorf("event %d kind = %v; want %v", i, event.Kind, want[i].Kind())
+		}
+
+		// Building an event from its message gives the same event back
+		if rebuilt := midi.NewMidiEvent(event.DeltaTick, m); rebuilt != event {
+			t.Errorf("event %d rebuilt as %+v; want %+v", i, rebuilt, event)
+		}
+	}
+
+	// Consumers can switch on the concrete type
+	switch m := events[4].Message().(type) {
+	case midi.ControlChange:
+		if m.Controller != 0x40 {
+			t.Errorf("controller = %d; want 64", m.Controller)
+		}
+	default:
+		t.Errorf("event 4 has type %T; want midi.ControlChange", m)
+	}
+}
+
+func Test_EventKindJSON(t *testing.T) {
+	event := midi.NewMidiEvent(10, midi.ProgramChange{Channel: 9, Program: 5})
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"kind":"ProgramChange","channel":9,"data":[5,0],"deltaTick":10}`; string(b) != want {
+		t.Errorf("json = %s; want %s", b, want)
+	}
+
+	var decoded midi.MidiEvent
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != event {
+		t.Errorf("decoded event = %+v; want %+v", decoded, event)
+	}
+	if event.Status() != 0xC9 {
+		t.Errorf("Status() = %#x; want 0xc9", event.Status())
+	}
+}
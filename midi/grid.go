@@ -0,0 +1,184 @@
+package midi
+
+import (
+	"fmt"
+	"math"
+)
+
+// Position type used to hold a musical position. Bars and beats count from one,
+// and Tick counts the ticks into the beat
+type Position struct {
+	Bar  int   `json:"bar"`
+	Beat int   `json:"beat"`
+	Tick int32 `json:"tick"`
+}
+
+// String returns the position as bar:beat:tick
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d:%d", p.Bar, p.Beat, p.Tick)
+}
+
+// TimeSignatureChange type used to hold a time signature change and the bar it
+// starts
+type TimeSignatureChange struct {
+	Tick          int32         `json:"tick"`
+	Bar           int           `json:"bar"`
+	TimeSignature TimeSignature `json:"timeSignature"`
+}
+
+// Grid type used to hold the time signature changes of a file and convert
+// between ticks and bars and beats. A beat is one note of the time signature's
+// denominator, so 6/8 has six beats per bar
+type Grid struct {
+	Changes         []TimeSignatureChange `json:"changes"`
+	TicksPerQuarter int                   `json:"ticksPerQuarter"`
+}
+
+// DefaultTimeSignature is the time signature of a MIDI file before its first
+// time signature change
+var DefaultTimeSignature = TimeSignature{Numerator: 4, Denominator: 4, ClocksPerClick: 24, ThirtySecondsPerQuarter: 8}
+
+// Grid creates the bar and beat grid of the file from its time signature events.
+// A time signature change in the middle of a bar ends that bar early. SMPTE files
+// have no ticks per quarter note, so the grid uses the length of a quarter note
+// at the first tempo
+func (f *MidiFile) Grid() Grid {
+	g := Grid{TicksPerQuarter: f.TimeDivision.TicksPerQuarter}
+	if f.TimeDivision.IsSMPTE() {
+		if perTick := f.TimeDivision.SecondsPerTick(0); perTick > 0 {
+			g.TicksPerQuarter = int(math.Round(float64(f.TempoMap.TempoAt(0)) / 1000000.0 / perTick))
+		}
+	}
+
+	events := f.MetaOfType(MetaTimeSignature)
+	if f.Format == FormatMultiSequence && len(f.Tracks) > 0 {
+		events = f.Tracks[0].MetaOfType(MetaTimeSignature)
+	}
+
+	for _, event := range events {
+		ts := event.TimeSignature
+		if ts == nil || ts.Numerator == 0 {
+			continue
+		}
+
+		change := TimeSignatureChange{Tick: event.Tick, Bar: 1, TimeSignature: *ts}
+
+		// A later change at the same tick replaces the earlier one
+		if n := len(g.Changes); n > 0 && g.Changes[n-1].Tick == event.Tick {
+			change.Bar = g.Changes[n-1].Bar
+			g.Changes[n-1] = change
+			continue
+		}
+
+		// A change in the middle of a bar starts the next one
+		if event.Tick > 0 {
+			p := g.Position(event.Tick)
+			change.Bar = p.Bar
+			if p.Beat != 1 || p.Tick != 0 {
+				change.Bar++
+			}
+		}
+
+		g.Changes = append(g.Changes, change)
+	}
+
+	return g
+}
+
+// section returns the time signature change in effect at the given tick. The
+// default time signature is returned as a change at the start of the file
+func (g *Grid) section(tick int32) TimeSignatureChange {
+	section := TimeSignatureChange{Bar: 1, TimeSignature: DefaultTimeSignature}
+
+	for _, change := range g.Changes {
+		if change.Tick > tick {
+			break
+		}
+		section = change
+	}
+
+	return section
+}
+
+// BeatTicks returns the number of ticks in one beat of the time signature
+func (g *Grid) BeatTicks(ts TimeSignature) int32 {
+	if ts.Denominator == 0 {
+		return int32(g.TicksPerQuarter)
+	}
+
+	return int32(g.TicksPerQuarter * 4 / int(ts.Denominator))
+}
+
+// TimeSignatureAt returns the time signature in effect at the given tick
+func (g *Grid) TimeSignatureAt(tick int32) TimeSignature {
+	return g.section(tick).TimeSignature
+}
+
+// Position converts an absolute tick to a position in bars and beats
+func (g *Grid) Position(tick int32) Position {
+	section := g.section(tick)
+	beat := g.BeatTicks(section.TimeSignature)
+	if beat <= 0 {
+		return Position{Bar: section.Bar, Beat: 1, Tick: tick - section.Tick}
+	}
+
+	beats := (tick - section.Tick) / beat
+	numerator := int32(section.TimeSignature.Numerator)
+
+	return Position{
+		Bar:  section.Bar + int(beats/numerator),
+		Beat: int(beats%numerator) + 1,
+		Tick: (tick - section.Tick) % beat,
+	}
+}
+
+// Tick converts a position in bars and beats to an absolute tick
+func (g *Grid) Tick(p Position) int32 {
+	section := TimeSignatureChange{Bar: 1, TimeSignature: DefaultTimeSignature}
+	for _, change := range g.Changes {
+		if change.Bar > p.Bar {
+			break
+		}
+		section = change
+	}
+
+	beat := g.BeatTicks(section.TimeSignature)
+	beats := int32(p.Bar-section.Bar)*int32(section.TimeSignature.Numerator) + int32(p.Beat-1)
+
+	return section.Tick + beats*beat + p.Tick
+}
+
+// Bars returns the ticks at which the bars starting before the given tick begin
+func (g *Grid) Bars(end int32) []int32 {
+	var bars []int32
+
+	for bar := 1; ; bar++ {
+		tick := g.Tick(Position{Bar: bar, Beat: 1})
+		if tick >= end || (len(bars) > 0 && tick <= bars[len(bars)-1]) {
+			break
+		}
+		bars = append(bars, tick)
+	}
+
+	return bars
+}
+
+// Beats returns the ticks at which the beats starting before the given tick begin
+func (g *Grid) Beats(end int32) []int32 {
+	var beats []int32
+
+	for _, bar := range g.Bars(end) {
+		section := g.section(bar)
+		ts := section.TimeSignature
+		for i := 0; i < int(ts.Numerator); i++ {
+			// A bar ended early by a time signature change has fewer beats
+			tick := bar + int32(i)*g.BeatTicks(ts)
+			if tick >= end || g.section(tick).Tick != section.Tick {
+				break
+			}
+			beats = append(beats, tick)
+		}
+	}
+
+	return beats
+}
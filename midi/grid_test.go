@@ -0,0 +1,115 @@
+package midi_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_Grid(t *testing.T) {
+	// Two bars of 4/4, then 3/4 cut short by 6/8 after two beats
+	track := "\x00\xff\x58\x04\x04\x02\x18\x08" +
+		"\x86\x00\xff\x58\x04\x03\x02\x18\x08" +
+		"\x81\x40\xff\x58\x04\x06\x03\x0c\x08"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(1, 96, track)); err != nil {
+		t.Fatal(err)
+	}
+
+	grid := f.Grid()
+	if bars := []int{grid.Changes[0].Bar, grid.Changes[1].Bar, grid.Changes[2].Bar}; len(grid.Changes) != 3 || !reflect.DeepEqual(bars, []int{1, 3, 4}) {
+		t.Fatalf("Grid().Changes = %+v; want changes starting bars 1, 3 and 4", grid.Changes)
+	}
+
+	tests := []struct {
+		tick     int32
+		position string
+	}{
+		{0, "1:1:0"},
+		{100, "1:2:4"},
+		{384, "2:1:0"},
+		{768, "3:1:0"},
+		{900, "3:2:36"},
+		{960, "4:1:0"},
+		{1298, "5:2:2"},
+	}
+
+	for _, test := range tests {
+		p := grid.Position(test.tick)
+		if p.String() != test.position {
+			t.Errorf("Position(%d) = %v; want %s", test.tick, p, test.position)
+		}
+		if tick := grid.Tick(p); tick != test.tick {
+			t.Errorf("Tick(%v) = %d; want %d", p, tick, test.tick)
+		}
+	}
+
+	if ts := grid.TimeSignatureAt(1000); ts.Numerator != 6 || ts.Denominator != 8 {
+		t.Errorf("TimeSignatureAt(1000) = %+v; want 6/8", ts)
+	}
+	if want := []int32{0, 384, 768, 960, 1248}; !reflect.DeepEqual(grid.Bars(1300), want) {
+		t.Errorf("Bars(1300) = %v; want %v", grid.Bars(1300), want)
+	}
+	if want := []int32{0, 96, 192, 288, 384, 480, 576, 672, 768, 864, 960}; !reflect.DeepEqual(grid.Beats(1000), want) {
+		t.Errorf("Beats(1000) = %v; want %v", grid.Beats(1000), want)
+	}
+
+	// Without time signatures, the file is in 4/4
+	if _, err := f.ParseBytes(smf(1, 96, "")); err != nil {
+		t.Fatal(err)
+	}
+	grid = f.Grid()
+	if p := grid.Position(96*9 + 5); p.String() != "3:2:5" {
+		t.Errorf("Position() = %v without time signatures; want 3:2:5", p)
+	}
+}
+
+func Test_KeySignature(t *testing.T) {
+	tests := []struct {
+		key   midi.KeySignature
+		name  string
+		tonic int
+	}{
+		{midi.KeySignature{Sharps: 0}, "C major", 0},
+		{midi.KeySignature{Sharps: 0, Minor: true}, "A minor", 9},
+		{midi.KeySignature{Sharps: -1, Minor: true}, "D minor", 2},
+		{midi.KeySignature{Sharps: -2}, "Bb major", 10},
+		{midi.KeySignature{Sharps: 2, Minor: true}, "B minor", 11},
+		{midi.KeySignature{Sharps: 7}, "C# major", 1},
+		{midi.KeySignature{Sharps: -7, Minor: true}, "Ab minor", 8},
+	}
+
+	for _, test := range tests {
+		if name := test.key.String(); name != test.name {
+			t.Errorf("%+v.String() = %q; want %q", test.key, name, test.name)
+		}
+		if tonic := test.key.Tonic(); tonic != test.tonic {
+			t.Errorf("%v.Tonic() = %d; want %d", test.key, tonic, test.tonic)
+		}
+	}
+
+	if name := (midi.KeySignature{Sharps: 9}).Name(); name != "unknown key" {
+		t.Errorf("Name() = %q for 9 sharps; want \"unknown key\"", name)
+	}
+
+	// D minor holds B flat but not B or C sharp
+	dMinor := midi.KeySignature{Sharps: -1, Minor: true}
+	for note, want := range map[byte]bool{62: true, 64: true, 70: true, 71: false, 72: true, 61: false} {
+		if got := dMinor.InKey(note); got != want {
+			t.Errorf("InKey(%d) = %v in D minor; want %v", note, got, want)
+		}
+	}
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(1, 96, "\x60\xff\x59\x02\xff\x01")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.KeySignatureAt(0); ok {
+		t.Error("KeySignatureAt(0) found a key before the first key signature")
+	}
+	if key, ok := f.KeySignatureAt(96); !ok || key != dMinor {
+		t.Errorf("KeySignatureAt(96) = %v, %v; want %v, true", key, ok, dMinor)
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"image"
 	"image/color"
 	"image/png"
+	"math"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 // Midi note conversion to piano note
@@ -192,25 +195,20 @@ var midiToName = map[byte]string{
 	108: "C8",
 }
 
-type NoteTrack struct {
-	name  string
-	notes []float64
-}
-
 // Conversion rate from pixels to millimeters
 const MILLI_CONVERSION_RATE = 0.2645833333
 
-// CreateImage function creates an image based on the MidiFile
-func CreateImage(file MidiFile, outputPath string) {
-	// Specific parameters for music box
-	var notes []string = []string{"C4", "D4", "E4", "F4", "G4"}
-	USER_NOTE_SIZE := 5.0
-	TRACK := 1
+// CreateImage function creates an image of the paper strip for the MidiFile on
+// the given music box. Notes the box cannot play are left out
+func CreateImage(file MidiFile, spec MusicBoxSpec, outputPath string) error {
+	layout, err := NewLayout(&file, spec)
+	if err != nil {
+		return err
+	}
 
 	// Important calculations for sheet size
-	noteSize := int(USER_NOTE_SIZE/MILLI_CONVERSION_RATE) + 1
-	height := noteSize * len(notes)
-	width := 200
+	height := int(spec.Width/MILLI_CONVERSION_RATE) + 1
+	width := int(layout.Length/MILLI_CONVERSION_RATE) + 1
 
 	// Get the top left and bottom right points of the image
 	topLeft := image.Point{0, 0}
@@ -221,12 +219,18 @@ func CreateImage(file MidiFile, outputPath string) {
 
 	// Create a color
 	black := color.RGBA{0, 0, 0, 0xFF}
+	gray := color.RGBA{0xC0, 0xC0, 0xC0, 0xFF}
 	white := color.RGBA{255, 255, 255, 0xFF}
 
-	// Create a grid
+	// Create a grid with a line along each tine's track
+	lines := make(map[int]bool)
+	for i := range spec.Notes {
+		lines[toPixels(spec.TineOffset(i))] = true
+	}
+
 	for x := 0; x < width; x++ {
 		for y := 0; y < height; y++ {
-			if y%noteSize == 0 {
+			if lines[y] {
 				img.Set(x, y, black)
 			} else {
 				img.Set(x, y, white)
@@ -234,29 +238,87 @@ func CreateImage(file MidiFile, outputPath string) {
 		}
 	}
 
-	// Add the notes
-	var noteTracks []NoteTrack
-	for i := 0; i < len(notes); i++ {
-		var track NoteTrack
-		track.name = notes[i]
-		noteTracks = append(noteTracks, track)
+	// Add the bar lines
+	for _, bar := range layout.Bars {
+		x := toPixels(bar)
+		for y := toPixels(spec.TineOffset(0)); y <= toPixels(spec.TineOffset(len(spec.Notes)-1)); y++ {
+			if !lines[y] {
+				img.Set(x, y, gray)
+			}
+		}
 	}
 
-	secondsPerTick := 60000.0 / float64(file.Tempo*int32(file.TimeDivision))
-
-	for _, midiNote := range file.Tracks[TRACK].Notes {
-		note := secondsPerTick * float64(midiNote.StartTime)
-
-		for _, track := range noteTracks {
-			if track.name == midiToName[midiNote.Key] {
-				track.notes = append(track.notes, note)
-				fmt.Println(len(track.notes))
-				break
+	// Add the holes
+	radius := spec.HoleDiameter / 2
+	for _, hole := range layout.Holes {
+		for x := toPixels(hole.X - radius); x <= toPixels(hole.X+radius); x++ {
+			for y := toPixels(hole.Y - radius); y <= toPixels(hole.Y+radius); y++ {
+				// Compare the pixel's center with the hole in millimeters
+				dx := (float64(x)+0.5)*MILLI_CONVERSION_RATE - hole.X
+				dy := (float64(y)+0.5)*MILLI_CONVERSION_RATE - hole.Y
+				if inHole(spec.HoleShape, dx, dy, radius) {
+					img.Set(x, y, black)
+				}
 			}
 		}
 	}
 
 	// Encode as PNG
-	f, _ := os.Create(outputPath)
-	png.Encode(f, img)
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// toPixels converts millimeters to the pixel containing them
+func toPixels(mm float64) int {
+	return int(math.Floor(mm / MILLI_CONVERSION_RATE))
+}
+
+// inHole returns true if a point at the given offset from the center of a hole
+// is inside it. A pixel wide stroke is kept for crosses so they stay visible
+func inHole(shape HoleShape, dx, dy, radius float64) bool {
+	switch shape {
+	case HoleSquare:
+		return math.Abs(dx) <= radius && math.Abs(dy) <= radius
+	case HoleCross:
+		stroke := MILLI_CONVERSION_RATE / 2
+		return math.Abs(dx) <= radius && math.Abs(dy) <= radius && (math.Abs(dx-dy) <= stroke || math.Abs(dx+dy) <= stroke)
+	}
+
+	return dx*dx+dy*dy <= radius*radius
+}
+
+// CreateSequenceImages function creates one image per song of the MidiFile. The
+// sequences of a format 2 file are numbered from one before the extension of the
+// output path, and any other file is drawn to the output path. The paths of the
+// created images are returned
+func CreateSequenceImages(file MidiFile, spec MusicBoxSpec, outputPath string) ([]string, error) {
+	if file.Format != FormatMultiSequence {
+		if err := CreateImage(file, spec, outputPath); err != nil {
+			return nil, err
+		}
+		return []string{outputPath}, nil
+	}
+
+	ext := filepath.Ext(outputPath)
+	base := strings.TrimSuffix(outputPath, ext)
+
+	var paths []string
+	for i, sequence := range file.Sequences() {
+		path := base + "-" + fmt.Sprint(i+1) + ext
+		if err := CreateImage(sequence, spec, path); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
 }
@@ -3,7 +3,7 @@ package midi_test
 import (
 	"testing"
 
-	"github.com/ethanbaker/midi-graphic"
+	"github.com/ethanbaker/midi-to-musicbox/midi"
 )
 
 func Test_ConvertImage(t *testing.T) {
@@ -11,5 +11,7 @@ func Test_ConvertImage(t *testing.T) {
 
 	f.Parse("./testing/midi.mid")
 
-	midi.CreateImage(f, "./testing/image.png")
+	if err := midi.CreateImage(f, midi.MusicBox30, "./testing/image.png"); err != nil {
+		t.Fatal(err)
+	}
 }
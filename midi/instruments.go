@@ -0,0 +1,159 @@
+package midi
+
+// PercussionChannel is the channel General MIDI reserves for percussion (channel
+// 10, counting from one)
+const PercussionChannel = 9
+
+// General MIDI instrument names, indexed by program number
+var gmInstruments = [128]string{
+	// Piano
+	"Acoustic Grand Piano", "Bright Acoustic Piano", "Electric Grand Piano", "Honky-tonk Piano",
+	"Electric Piano 1", "Electric Piano 2", "Harpsichord", "Clavinet",
+	// Chromatic Percussion
+	"Celesta", "Glockenspiel", "Music Box", "Vibraphone",
+	"Marimba", "Xylophone", "Tubular Bells", "Dulcimer",
+	// Organ
+	"Drawbar Organ", "Percussive Organ", "Rock Organ", "Church Organ",
+	"Reed Organ", "Accordion", "Harmonica", "Tango Accordion",
+	// Guitar
+	"Acoustic Guitar (nylon)", "Acoustic Guitar (steel)", "Electric Guitar (jazz)", "Electric Guitar (clean)",
+	"Electric Guitar (muted)", "Overdriven Guitar", "Distortion Guitar", "Guitar Harmonics",
+	// Bass
+	"Acoustic Bass", "Electric Bass (finger)", "Electric Bass (pick)", "Fretless Bass",
+	"Slap Bass 1", "Slap Bass 2", "Synth Bass 1", "Synth Bass 2",
+	// Strings
+	"Violin", "Viola", "Cello", "Contrabass",
+	"Tremolo Strings", "Pizzicato Strings", "Orchestral Harp", "Timpani",
+	// Ensemble
+	"String Ensemble 1", "String Ensemble 2", "Synth Strings 1", "Synth Strings 2",
+	"Choir Aahs", "Voice Oohs", "Synth Voice", "Orchestra Hit",
+	// Brass
+	"Trumpet", "Trombone", "Tuba", "Muted Trumpet",
+	"French Horn", "Brass Section", "Synth Brass 1", "Synth Brass 2",
+	// Reed
+	"Soprano Sax", "Alto Sax", "Tenor Sax", "Baritone Sax",
+	"Oboe", "English Horn", "Bassoon", "Clarinet",
+	// Pipe
+	"Piccolo", "Flute", "Recorder", "Pan Flute",
+	"Blown Bottle", "Shakuhachi", "Whistle", "Ocarina",
+	// Synth Lead
+	"Lead 1 (square)", "Lead 2 (sawtooth)", "Lead 3 (calliope)", "Lead 4 (chiff)",
+	"Lead 5 (charang)", "Lead 6 (voice)", "Lead 7 (fifths)", "Lead 8 (bass + lead)",
+	// Synth Pad
+	"Pad 1 (new age)", "Pad 2 (warm)", "Pad 3 (polysynth)", "Pad 4 (choir)",
+	"Pad 5 (bowed)", "Pad 6 (metallic)", "Pad 7 (halo)", "Pad 8 (sweep)",
+	// Synth Effects
+	"FX 1 (rain)", "FX 2 (soundtrack)", "FX 3 (crystal)", "FX 4 (atmosphere)",
+	"FX 5 (brightness)", "FX 6 (goblins)", "FX 7 (echoes)", "FX 8 (sci-fi)",
+	// Ethnic
+	"Sitar", "Banjo", "Shamisen", "Koto",
+	"Kalimba", "Bagpipe", "Fiddle", "Shanai",
+	// Percussive
+	"Tinkle Bell", "Agogo", "Steel Drums", "Woodblock",
+	"Taiko Drum", "Melodic Tom", "Synth Drum", "Reverse Cymbal",
+	// Sound Effects
+	"Guitar Fret Noise", "Breath Noise", "Seashore", "Bird Tweet",
+	"Telephone Ring", "Helicopter", "Applause", "Gunshot",
+}
+
+// General MIDI instrument families. Each family holds eight programs
+var gmFamilies = [16]string{
+	"Piano", "Chromatic Percussion", "Organ", "Guitar",
+	"Bass", "Strings", "Ensemble", "Brass",
+	"Reed", "Pipe", "Synth Lead", "Synth Pad",
+	"Synth Effects", "Ethnic", "Percussive", "Sound Effects",
+}
+
+// InstrumentName returns the General MIDI name of a program
+func InstrumentName(program byte) string {
+	return gmInstruments[program&0x7F]
+}
+
+// InstrumentFamily returns the General MIDI family of a program
+func InstrumentFamily(program byte) string {
+	return gmFamilies[(program&0x7F)/8]
+}
+
+// ProgramChangeEvent type used to hold a program change with its absolute tick
+type ProgramChangeEvent struct {
+	Tick    int32 `json:"tick"`
+	Channel byte  `json:"channel"`
+	Program byte  `json:"program"`
+}
+
+// IsPercussion returns true if the program change is on the percussion channel,
+// where programs select drum kits instead of instruments
+func (p ProgramChangeEvent) IsPercussion() bool {
+	return p.Channel == PercussionChannel
+}
+
+// Name returns the General MIDI name of the instrument
+func (p ProgramChangeEvent) Name() string {
+	if p.IsPercussion() {
+		return "Drum Kit"
+	}
+
+	return InstrumentName(p.Program)
+}
+
+// Family returns the General MIDI family of the instrument
+func (p ProgramChangeEvent) Family() string {
+	if p.IsPercussion() {
+		return "Drums"
+	}
+
+	return InstrumentFamily(p.Program)
+}
+
+// IsPercussion returns true if the note is on the percussion channel
+func (n MidiNote) IsPercussion() bool {
+	return n.Channel == PercussionChannel
+}
+
+// collectPrograms records the track's program changes. If the track has no
+// instrument name, the instrument of its first program change is used
+func (t *MidiTrack) collectPrograms() {
+	var wallTime int32
+
+	for _, event := range t.Events {
+		wallTime += event.DeltaTick
+		if event.Kind == KindProgramChange {
+			t.Programs = append(t.Programs, ProgramChangeEvent{wallTime, event.Channel, event.Data[0]})
+		}
+	}
+
+	if t.Instrument == "" && len(t.Programs) > 0 {
+		t.Instrument = t.Programs[0].Name()
+	}
+}
+
+// ProgramAt returns the program selected on a channel at the given tick. False
+// is returned if no program change has happened on the channel yet
+func (t *MidiTrack) ProgramAt(channel byte, tick int32) (byte, bool) {
+	var program byte
+	found := false
+
+	for _, p := range t.Programs {
+		if p.Tick > tick {
+			break
+		}
+		if p.Channel == channel {
+			program = p.Program
+			found = true
+		}
+	}
+
+	return program, found
+}
+
+// IsPercussion returns true if the track has notes and all of them are on the
+// percussion channel
+func (t *MidiTrack) IsPercussion() bool {
+	for _, note := range t.Notes {
+		if !note.IsPercussion() {
+			return false
+		}
+	}
+
+	return len(t.Notes) > 0
+}
@@ -0,0 +1,94 @@
+package midi_test
+
+import (
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_InstrumentNames(t *testing.T) {
+	tests := []struct {
+		program      byte
+		name, family string
+	}{
+		{0, "Acoustic Grand Piano", "Piano"},
+		{10, "Music Box", "Chromatic Percussion"},
+		{40, "Violin", "Strings"},
+		{73, "Flute", "Pipe"},
+		{127, "Gunshot", "Sound Effects"},
+	}
+
+	for _, test := range tests {
+		if name := midi.InstrumentName(test.program); name != test.name {
+			t.Errorf("InstrumentName(%d) = %q; want %q", test.program, name, test.name)
+		}
+		if family := midi.InstrumentFamily(test.program); family != test.family {
+			t.Errorf("InstrumentFamily(%d) = %q; want %q", test.program, family, test.family)
+		}
+	}
+}
+
+func Test_Programs(t *testing.T) {
+	var f midi.MidiFile
+	if _, err := f.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []int{1, 2} {
+		if f.Tracks[i].Instrument != "Acoustic Grand Piano" {
+			t.Errorf("track %d instrument = %q; want Acoustic Grand Piano", i, f.Tracks[i].Instrument)
+		}
+	}
+
+	// A flute that becomes a violin, and a drum part
+	track := "\x00\xc0\x49" +
+		"\x00\xc9\x00" +
+		"\x00\x90\x3c\x40" +
+		"\x00\x99\x24\x40" +
+		"\x0a\x80\x3c\x00" +
+		"\x00\x89\x24\x00" +
+		"\x0a\xc0\x28" +
+		"\x00\x90\x3e\x40" +
+		"\x0a\x80\x3e\x00"
+	if _, err := f.ParseBytes(smf(0, 96, track)); err != nil {
+		t.Fatal(err)
+	}
+
+	programs := f.Tracks[0].Programs
+	if len(programs) != 3 {
+		t.Fatalf("%d program changes; want 3", len(programs))
+	}
+	if p := programs[0]; p.Name() != "Flute" || p.Family() != "Pipe" || p.IsPercussion() {
+		t.Errorf("first program = %+v (%s, %s)", p, p.Name(), p.Family())
+	}
+	if p := programs[1]; p.Name() != "Drum Kit" || !p.IsPercussion() {
+		t.Errorf("second program = %+v (%s)", p, p.Name())
+	}
+	if p := programs[2]; p.Tick != 20 || p.Name() != "Violin" {
+		t.Errorf("third program = %+v (%s)", p, p.Name())
+	}
+
+	if program, ok := f.Tracks[0].ProgramAt(0, 19); !ok || program != 73 {
+		t.Errorf("ProgramAt(0, 19) = %d, %v; want 73, true", program, ok)
+	}
+	if program, ok := f.Tracks[0].ProgramAt(0, 20); !ok || program != 40 {
+		t.Errorf("ProgramAt(0, 20) = %d, %v; want 40, true", program, ok)
+	}
+	if _, ok := f.Tracks[0].ProgramAt(1, 20); ok {
+		t.Error("ProgramAt() found a program on an unused channel")
+	}
+	if f.Tracks[0].IsPercussion() {
+		t.Error("mixed track reported as percussion")
+	}
+
+	// Splitting separates the drum part into a percussion track
+	f.SplitChannels()
+	if len(f.Tracks) != 2 {
+		t.Fatalf("%d split tracks; want 2", len(f.Tracks))
+	}
+	if f.Tracks[0].IsPercussion() || f.Tracks[0].Instrument != "Flute" {
+		t.Errorf("channel 0 track: percussion %v, instrument %q", f.Tracks[0].IsPercussion(), f.Tracks[0].Instrument)
+	}
+	if !f.Tracks[1].IsPercussion() || f.Tracks[1].Instrument != "Drum Kit" {
+		t.Errorf("channel 9 track: percussion %v, instrument %q", f.Tracks[1].IsPercussion(), f.Tracks[1].Instrument)
+	}
+}
@@ -0,0 +1,96 @@
+package midi
+
+import (
+	"sort"
+	"strings"
+)
+
+// Lyric type used to hold a single timed syllable of a song's lyrics
+type Lyric struct {
+	Tick         int32   `json:"tick"`
+	Seconds      float64 `json:"seconds"`
+	Track        int     `json:"track"`
+	Text         string  `json:"text"`
+	NewLine      bool    `json:"newLine"`      // The syllable starts a new line
+	NewParagraph bool    `json:"newParagraph"` // The syllable starts a new verse or page
+}
+
+// IsKaraoke returns true if the file is a karaoke (.kar) file, which keeps its
+// lyrics in text events instead of lyric events
+func (f *MidiFile) IsKaraoke() bool {
+	for _, event := range f.MetaOfType(MetaText) {
+		if strings.HasPrefix(event.Text, "@KMIDI") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KaraokeInfo returns the information text events of a karaoke file, such as
+// "@T" for titles and "@L" for the language, with the '@' removed
+func (f *MidiFile) KaraokeInfo() []string {
+	var info []string
+
+	for _, event := range f.MetaOfType(MetaText) {
+		if strings.HasPrefix(event.Text, "@") {
+			info = append(info, event.Text[1:])
+		}
+	}
+
+	return info
+}
+
+// Lyrics returns the syllables of the song ordered by seconds, then by tick.
+// Karaoke files take them from text events, where a leading '/' starts a new line
+// and a leading '\' a new paragraph. Other files take them from lyric events,
+// where a trailing carriage return or line feed ends the line
+func (f *MidiFile) Lyrics() []Lyric {
+	karaoke := f.IsKaraoke()
+
+	var lyrics []Lyric
+	for i := range f.Tracks {
+		breakNext := false
+		tempoMap := f.TrackTempoMap(i)
+
+		for _, event := range f.Tracks[i].Meta {
+			lyric := Lyric{Tick: event.Tick, Seconds: tempoMap.TickToSeconds(event.Tick), Track: i, Text: event.Text}
+
+			if karaoke {
+				if event.Type != MetaText || strings.HasPrefix(lyric.Text, "@") {
+					continue
+				}
+
+				if strings.HasPrefix(lyric.Text, "\\") {
+					lyric.NewParagraph = true
+					lyric.NewLine = true
+					lyric.Text = lyric.Text[1:]
+				} else if strings.HasPrefix(lyric.Text, "/") {
+					lyric.NewLine = true
+					lyric.Text = lyric.Text[1:]
+				}
+			} else {
+				if event.Type != MetaLyrics {
+					continue
+				}
+
+				// A line break ending the previous syllable starts this one on a new line
+				lyric.NewLine = breakNext
+				trimmed := strings.TrimRight(lyric.Text, "\r\n")
+				breakNext = trimmed != lyric.Text
+				lyric.Text = trimmed
+			}
+
+			lyrics = append(lyrics, lyric)
+		}
+	}
+
+	sort.SliceStable(lyrics, func(i, j int) bool {
+		if lyrics[i].Seconds != lyrics[j].Seconds {
+			return lyrics[i].Seconds < lyrics[j].Seconds
+		}
+		return lyrics[i].Tick < lyrics[j].Tick
+	})
+
+	return lyrics
+}
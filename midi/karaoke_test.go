@@ -0,0 +1,80 @@
+package midi_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_KaraokeLyrics(t *testing.T) {
+	info := "\x00\xff\x01\x13@KMIDI KARAOKE FILE" +
+		"\x00\xff\x01\x0e@TTwinkle Star"
+	words := "\x00\xff\x03\x05Words" +
+		"\x00\xff\x01\x05\\Twin" +
+		"\x60\xff\x01\x04kle " +
+		"\x60\xff\x01\x04/Lit" +
+		"\x60\xff\x01\x03tle"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(1, 96, info, words)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !f.IsKaraoke() {
+		t.Fatal("IsKaraoke() = false")
+	}
+	if info := f.KaraokeInfo(); !reflect.DeepEqual(info, []string{"KMIDI KARAOKE FILE", "TTwinkle Star"}) {
+		t.Errorf("KaraokeInfo() = %q", info)
+	}
+
+	want := []midi.Lyric{
+		{Tick: 0, Seconds: 0, Track: 1, Text: "Twin", NewLine: true, NewParagraph: true},
+		{Tick: 96, Seconds: 0.5, Track: 1, Text: "kle "},
+		{Tick: 192, Seconds: 1, Track: 1, Text: "Lit", NewLine: true},
+		{Tick: 288, Seconds: 1.5, Track: 1, Text: "tle"},
+	}
+	if lyrics := f.Lyrics(); !reflect.DeepEqual(lyrics, want) {
+		t.Errorf("Lyrics() = %+v; want %+v", lyrics, want)
+	}
+}
+
+func Test_LyricEvents(t *testing.T) {
+	track := "\x00\xff\x05\x03Do \x60\xff\x05\x03re\r\x60\xff\x05\x02mi"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(0, 96, track)); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.IsKaraoke() {
+		t.Error("IsKaraoke() = true for a plain file")
+	}
+	want := []midi.Lyric{
+		{Tick: 0, Seconds: 0, Text: "Do "},
+		{Tick: 96, Seconds: 0.5, Text: "re"},
+		{Tick: 192, Seconds: 1, Text: "mi", NewLine: true},
+	}
+	if lyrics := f.Lyrics(); !reflect.DeepEqual(lyrics, want) {
+		t.Errorf("Lyrics() = %+v; want %+v", lyrics, want)
+	}
+}
+
+func Test_LyricsOrder(t *testing.T) {
+	// Two songs: a lyric at 1 second in one at 60 BPM, and at 0.75 seconds in one at 120 BPM
+	first := "\x00\xff\x51\x03\x0f\x42\x40\x60\xff\x05\x03one"
+	second := "\x00\xff\x51\x03\x07\xa1\x20\x81\x10\xff\x05\x03two"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(2, 96, first, second)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []midi.Lyric{
+		{Tick: 144, Seconds: 0.75, Track: 1, Text: "two"},
+		{Tick: 96, Seconds: 1, Track: 0, Text: "one"},
+	}
+	if lyrics := f.Lyrics(); !reflect.DeepEqual(lyrics, want) {
+		t.Errorf("Lyrics() = %+v; want %+v", lyrics, want)
+	}
+}
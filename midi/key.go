@@ -0,0 +1,82 @@
+package midi
+
+// Names of the major and minor keys, indexed by the number of sharps plus seven
+var (
+	majorKeys = [15]string{"Cb", "Gb", "Db", "Ab", "Eb", "Bb", "F", "C", "G", "D", "A", "E", "B", "F#", "C#"}
+	minorKeys = [15]string{"Ab", "Eb", "Bb", "F", "C", "G", "D", "A", "E", "B", "F#", "C#", "G#", "D#", "A#"}
+)
+
+// Pitch classes of the major scale, counted from its tonic
+var majorScale = [7]int{0, 2, 4, 5, 7, 9, 11}
+
+// valid returns true if the number of sharps or flats is in range
+func (k KeySignature) valid() bool {
+	return k.Sharps >= -7 && k.Sharps <= 7
+}
+
+// Name returns the name of the key, such as "D minor" or "Bb major"
+func (k KeySignature) Name() string {
+	if !k.valid() {
+		return "unknown key"
+	}
+
+	if k.Minor {
+		return minorKeys[k.Sharps+7] + " minor"
+	}
+
+	return majorKeys[k.Sharps+7] + " major"
+}
+
+// String returns the name of the key
+func (k KeySignature) String() string {
+	return k.Name()
+}
+
+// relativeMajor returns the pitch class of the tonic of the major key sharing
+// the key's signature, with C as 0
+func (k KeySignature) relativeMajor() int {
+	// Each sharp moves the tonic up a fifth
+	return ((int(k.Sharps)*7)%12 + 12) % 12
+}
+
+// Tonic returns the pitch class of the key's tonic, with C as 0
+func (k KeySignature) Tonic() int {
+	if k.Minor {
+		return (k.relativeMajor() + 9) % 12
+	}
+
+	return k.relativeMajor()
+}
+
+// InKey returns true if the MIDI note belongs to the key's scale. Minor keys use
+// the natural minor scale
+func (k KeySignature) InKey(note byte) bool {
+	class := (int(note) - k.relativeMajor() + 12) % 12
+
+	for _, degree := range majorScale {
+		if class == degree {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KeySignatureAt returns the key signature in effect at the given tick. False is
+// returned if the file has no key signature before the tick
+func (f *MidiFile) KeySignatureAt(tick int32) (KeySignature, bool) {
+	var key KeySignature
+	found := false
+
+	for _, event := range f.MetaOfType(MetaKeySignature) {
+		if event.Tick > tick {
+			break
+		}
+		if event.KeySignature != nil {
+			key = *event.KeySignature
+			found = true
+		}
+	}
+
+	return key, found
+}
@@ -0,0 +1,94 @@
+package midi
+
+import (
+	"errors"
+	"math"
+)
+
+// HoleShape type used to choose how a note is marked on the strip
+type HoleShape byte
+
+const (
+	HoleCircle HoleShape = iota // A round punch hole
+	HoleSquare                  // A square punch hole
+	HoleCross                   // A cross marking where to punch by hand
+)
+
+// Hole type used to hold a punch hole on the strip. X is the distance along the
+// strip from its start, and Y the distance across it from the top edge, both in
+// millimeters to the center of the hole
+type Hole struct {
+	X    float64   `json:"x"`
+	Y    float64   `json:"y"`
+	Tine int       `json:"tine"`
+	Note TimedNote `json:"note"`
+}
+
+// Layout type used to hold the position of everything drawn on a strip, in
+// millimeters. Every renderer draws from the same layout
+type Layout struct {
+	Title  string       `json:"title"` // Name of the first track, if it has one
+	Spec   MusicBoxSpec `json:"spec"`
+	Length float64      `json:"length"` // Length of the strip, including the lead in and lead out
+	Holes  []Hole       `json:"holes"`
+	Bars   []float64    `json:"bars"`  // Distance along the strip of each bar line
+	Beats  []float64    `json:"beats"` // Distance along the strip of each beat line, including those on bar lines
+
+	// Skipped holds the notes the music box has no tine for, and the percussion
+	// notes unless the spec keeps them
+	Skipped []TimedNote `json:"skipped"`
+}
+
+// NewLayout lays out the notes of the file on a strip for the given music box.
+// The sequences of a format 2 file are separate songs, so such a file must be
+// laid out one sequence at a time from Sequences
+func NewLayout(file *MidiFile, spec MusicBoxSpec) (Layout, error) {
+	if err := spec.Validate(); err != nil {
+		return Layout{}, err
+	}
+	if file.Format == FormatMultiSequence && len(file.Tracks) > 1 {
+		return Layout{}, errors.New("midi: cannot lay out several format 2 sequences on one strip, use Sequences to lay out each one")
+	}
+
+	l := Layout{Spec: spec}
+	if len(file.Tracks) > 0 {
+		l.Title = file.Tracks[0].Name
+	}
+	grid := file.Grid()
+
+	// The strip runs until the last note has finished
+	var end float64
+	var endTick int32
+	for _, note := range file.Timeline() {
+		// Drums have no pitch to play
+		tine, ok := spec.Tine(note.Key)
+		if !ok || (note.IsPercussion() && !spec.Percussion) {
+			l.Skipped = append(l.Skipped, note)
+			continue
+		}
+
+		// Only the notes punched into the strip make it longer
+		end = math.Max(end, spec.distance(note.EndTick, note.End, grid.TicksPerQuarter))
+		if note.EndTick > endTick {
+			endTick = note.EndTick
+		}
+
+		l.Holes = append(l.Holes, Hole{
+			X:    spec.LeadIn + spec.distance(note.StartTick, note.Start, grid.TicksPerQuarter),
+			Y:    spec.TineOffset(tine),
+			Tine: tine,
+			Note: note,
+		})
+	}
+	l.Length = spec.LeadIn + end + spec.LeadOut
+
+	// Bar and beat lines are placed by tick, so they follow the paper speed like the notes
+	for _, tick := range grid.Bars(endTick) {
+		l.Bars = append(l.Bars, spec.LeadIn+spec.distance(tick, file.TempoMap.TickToSeconds(tick), grid.TicksPerQuarter))
+	}
+	for _, tick := range grid.Beats(endTick) {
+		l.Beats = append(l.Beats, spec.LeadIn+spec.distance(tick, file.TempoMap.TickToSeconds(tick), grid.TicksPerQuarter))
+	}
+
+	return l, nil
+}
@@ -0,0 +1,142 @@
+package midi_test
+
+import (
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+// layoutFile returns a file at 120 BPM in 4/4 with notes C4 and E4 on the first
+// two beats and C#4 on the third, each lasting a beat
+func layoutFile(t *testing.T) midi.MidiFile {
+	track := "\x00\x90\x3c\x40\x60\x80\x3c\x00" +
+		"\x00\x90\x40\x40\x60\x80\x40\x00" +
+		"\x00\x90\x3d\x40\x60\x80\x3d\x00"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(0, 96, track)); err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func Test_Layout(t *testing.T) {
+	f := layoutFile(t)
+	spec := midi.MusicBoxSpec{Notes: []byte{60, 62, 64}, Pitch: 3, Width: 12, Margin: 2, LeadIn: 10, LeadOut: 5, HoleDiameter: 2, MillimetersPerBeat: 8}
+
+	layout, err := midi.NewLayout(&f, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The holes sit on their tine's track, a beat apart
+	want := [][3]float64{{10, 2, 0}, {18, 8, 2}}
+	if len(layout.Holes) != len(want) {
+		t.Fatalf("%d holes; want %d", len(layout.Holes), len(want))
+	}
+	for i, hole := range layout.Holes {
+		if got := [3]float64{hole.X, hole.Y, float64(hole.Tine)}; got != want[i] {
+			t.Errorf("hole %d at %v; want %v", i, got, want[i])
+		}
+	}
+
+	// C#4 has no tine
+	if len(layout.Skipped) != 1 || layout.Skipped[0].Key != 0x3d {
+		t.Errorf("Skipped = %+v; want C#4", layout.Skipped)
+	}
+
+	// Two beats of notes between the lead in and lead out, with a single bar. The
+	// skipped C#4 does not lengthen the strip
+	if layout.Length != 10+16+5 {
+		t.Errorf("Length = %v; want 31", layout.Length)
+	}
+	if !reflect.DeepEqual(layout.Bars, []float64{10}) {
+		t.Errorf("Bars = %v; want [10]", layout.Bars)
+	}
+
+	// Paper speed per second follows the tempo
+	spec.MillimetersPerBeat = 0
+	spec.MillimetersPerSecond = 10
+	if layout, err = midi.NewLayout(&f, spec); err != nil {
+		t.Fatal(err)
+	}
+	if x := layout.Holes[1].X; math.Abs(x-15) > 1e-9 {
+		t.Errorf("second hole at %v; want 15", x)
+	}
+}
+
+// pixels converts millimeters to image pixels
+func pixels(mm float64) int {
+	return int(mm / midi.MILLI_CONVERSION_RATE)
+}
+
+func Test_ImageHoles(t *testing.T) {
+	f := layoutFile(t)
+	path := filepath.Join(t.TempDir(), "image.png")
+
+	for _, shape := range []midi.HoleShape{midi.HoleCircle, midi.HoleSquare, midi.HoleCross} {
+		spec := midi.MusicBoxSpec{Notes: []byte{60, 62, 64}, Pitch: 3, Width: 12, Margin: 2, LeadIn: 10, LeadOut: 5, HoleDiameter: 2, HoleShape: shape, MillimetersPerBeat: 8}
+		if err := midi.CreateImage(f, spec, path); err != nil {
+			t.Fatal(err)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The image is as long as the strip
+		if width := img.Bounds().Dx(); width != pixels(31)+1 {
+			t.Errorf("shape %d: image is %d pixels wide; want %d", shape, width, pixels(31)+1)
+		}
+
+		// The center of the first hole is marked, and the space between tines is not
+		x, y := pixels(10), pixels(2)
+		if r, _, _, _ := img.At(x, y).RGBA(); r != 0 {
+			t.Errorf("shape %d: hole center is not marked", shape)
+		}
+		if r, _, _, _ := img.At(x, pixels(3.5)).RGBA(); r == 0 {
+			t.Errorf("shape %d: space between tines is marked", shape)
+		}
+	}
+}
+
+func Test_LayoutPercussion(t *testing.T) {
+	// C4 on the first channel and on the percussion channel
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(0, 96, "\x00\x90\x3c\x40\x00\x99\x3c\x40\x60\x80\x3c\x00\x00\x89\x3c\x00")); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := midi.MusicBox30
+	layout, err := midi.NewLayout(&f, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layout.Holes) != 1 || layout.Holes[0].Note.IsPercussion() {
+		t.Errorf("Holes = %+v; want the melodic note only", layout.Holes)
+	}
+	if len(layout.Skipped) != 1 || !layout.Skipped[0].IsPercussion() {
+		t.Errorf("Skipped = %+v; want the percussion note", layout.Skipped)
+	}
+
+	// The spec can keep them
+	spec.Percussion = true
+	if layout, err = midi.NewLayout(&f, spec); err != nil {
+		t.Fatal(err)
+	}
+	if len(layout.Holes) != 2 || len(layout.Skipped) != 0 {
+		t.Errorf("%d holes and %d skipped notes with percussion kept; want 2 and 0", len(layout.Holes), len(layout.Skipped))
+	}
+}
@@ -0,0 +1,100 @@
+package midi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// warn records a problem that lenient mode repaired
+func (f *MidiFile) warn(err error, msg string) {
+	f.Warnings = append(f.Warnings, ParseError{Err: err, Msg: msg, Offset: f.offset, Track: f.trackIndex})
+	f.logf("Warning! %s", msg)
+}
+
+// atTrackHeader returns true if the next bytes are a track chunk ID
+func (f *MidiFile) atTrackHeader() bool {
+	b, err := f.reader.Peek(4)
+	return err == nil && string(b) == "MTrk"
+}
+
+// scanForTrack reads forward until the next track chunk ID, the given offset or
+// the end of the file. A limit of -1 scans to the end of the file. True is
+// returned if a track was found before the limit
+func (f *MidiFile) scanForTrack(limit int64) (bool, error) {
+	for limit < 0 || f.offset < limit {
+		if f.atTrackHeader() {
+			return true, nil
+		}
+
+		if _, err := f.reader.ReadByte(); err != nil {
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, f.handleError(err)
+		}
+		f.offset++
+	}
+
+	return false, nil
+}
+
+// parseLenientTrack reads the events of a track without trusting its length. A
+// track that cannot be read to its end keeps the events read so far, and parsing
+// continues at the next track chunk found
+func (f *MidiFile) parseLenientTrack(trackIndex int, declaredEnd int64) error {
+	declaredLength := declaredEnd - f.offset
+	f.chunkEnd = -1
+
+	if err := f.parseTrack(trackIndex); err != nil {
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			return err
+		}
+
+		f.Warnings = append(f.Warnings, *parseErr)
+		_, err := f.scanForTrack(-1)
+		return err
+	}
+
+	// Check the track ended where its length said it would
+	if f.offset > declaredEnd {
+		f.warn(ErrBadTrackHeader, "track is longer than its length of "+fmt.Sprint(declaredLength)+" bytes")
+	} else if f.offset < declaredEnd {
+		found, err := f.scanForTrack(declaredEnd)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			f.warn(ErrBadTrackHeader, "track length of "+fmt.Sprint(declaredLength)+" bytes runs into the next track")
+		} else if f.offset < declaredEnd {
+			f.warn(ErrTruncated, "track length of "+fmt.Sprint(declaredLength)+" bytes runs past the end of the file")
+		}
+	}
+
+	return nil
+}
+
+// closeUnclosed closes the notes of a track that were never released at the End
+// of Track event
+func (f *MidiFile) closeUnclosed(trackIndex int) {
+	t := &f.Tracks[trackIndex]
+	if len(t.Unclosed) == 0 {
+		return
+	}
+
+	f.trackIndex = trackIndex
+	f.warn(ErrTruncated, fmt.Sprint(len(t.Unclosed))+" notes were never released and end with the track")
+	f.trackIndex = -1
+
+	for _, note := range t.Unclosed {
+		t.addNote(note)
+	}
+	t.Unclosed = nil
+
+	sort.SliceStable(t.Notes, func(i, j int) bool {
+		return t.Notes[i].StartTime < t.Notes[j].StartTime
+	})
+}
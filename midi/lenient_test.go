@@ -0,0 +1,145 @@
+package midi_test
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+// header returns a format 1 header chunk with the given number of tracks
+func header(tracks int) string {
+	return "MThd\x00\x00\x00\x06\x00\x01\x00" + string(rune(tracks)) + "\x00\x60"
+}
+
+// chunk returns a track chunk claiming the given length
+func chunk(length int, data string) string {
+	return "MTrk\x00\x00" + string([]byte{byte(length >> 8), byte(length)}) + data
+}
+
+func Test_Lenient(t *testing.T) {
+	note := "\x00\x90\x3c\x40\x10\x80\x3c\x00"
+	eot := "\x00\xff\x2f\x00"
+
+	tests := []struct {
+		name     string
+		data     string
+		notes    []int // Number of notes in each track
+		warnings []error
+	}{
+		{"length too short", header(2) + chunk(4, note+eot) + chunk(12, note+eot), []int{1, 1}, []error{midi.ErrBadTrackHeader}},
+		{"length too long", header(2) + chunk(200, note+eot) + chunk(12, note+eot), []int{1, 1}, []error{midi.ErrBadTrackHeader}},
+		{"missing end of track", header(2) + chunk(8, note) + chunk(12, note+eot), []int{1, 1}, []error{midi.ErrTruncated}},
+		{"running status after meta", header(1) + chunk(16, "\x00\x90\x3c\x40\x00\xff\x01\x01x\x10\x3c\x00"+eot), []int{1}, []error{midi.ErrUnknownStatus}},
+		{"garbage between tracks", header(2) + chunk(12, note+eot) + "\x00\x01\x02" + chunk(12, note+eot), []int{1, 1}, []error{midi.ErrBadTrackHeader}},
+		{"truncated file", header(2) + chunk(12, note+eot) + chunk(12, "\x00\x90\x3c\x40\x10\x90\x3e"), []int{1, 1}, []error{midi.ErrTruncated, midi.ErrTruncated}},
+		{"missing tracks", header(3) + chunk(12, note+eot), []int{1}, []error{midi.ErrTruncated}},
+	}
+
+	for _, test := range tests {
+		// Strict mode refuses the file
+		var strict midi.MidiFile
+		if ok, err := strict.ParseBytes([]byte(test.data)); ok || err == nil {
+			t.Errorf("%s: strict Parse() = %v, %v; want an error", test.name, ok, err)
+		}
+
+		lenient := midi.MidiFile{Lenient: true}
+		if ok, err := lenient.ParseBytes([]byte(test.data)); !ok || err != nil {
+			t.Errorf("%s: lenient Parse() = %v, %v; want true, nil", test.name, ok, err)
+			continue
+		}
+
+		if len(lenient.Tracks) != len(test.notes) {
+			t.Errorf("%s: %d tracks; want %d", test.name, len(lenient.Tracks), len(test.notes))
+			continue
+		}
+		for i, n := range test.notes {
+			if len(lenient.Tracks[i].Notes) != n || len(lenient.Tracks[i].Unclosed) != 0 {
+				t.Errorf("%s: track %d has %d notes and %d unclosed; want %d and 0", test.name, i, len(lenient.Tracks[i].Notes), len(lenient.Tracks[i].Unclosed), n)
+			}
+		}
+
+		if len(lenient.Warnings) != len(test.warnings) {
+			t.Errorf("%s: warnings = %v; want %d", test.name, lenient.Warnings, len(test.warnings))
+			continue
+		}
+		for i, want := range test.warnings {
+			if !errors.Is(&lenient.Warnings[i], want) {
+				t.Errorf("%s: warning %d = %v; want %v", test.name, i, &lenient.Warnings[i], want)
+			}
+		}
+	}
+}
+
+func Test_LenientValidFile(t *testing.T) {
+	strict := midi.MidiFile{}
+	lenient := midi.MidiFile{Lenient: true}
+	if _, err := strict.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lenient.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lenient.Warnings) != 0 {
+		t.Errorf("valid file gave warnings %v", lenient.Warnings)
+	}
+	compareFiles(t, "lenient", &lenient, &strict)
+}
+
+func Test_LenientUnclosed(t *testing.T) {
+	// A note that is never released, with End of Track 480 ticks later
+	f := midi.MidiFile{Lenient: true}
+	if _, err := f.ParseBytes([]byte(header(1) + chunk(9, "\x00\x90\x3c\x40\x83\x60\xff\x2f\x00"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(f.Warnings) != 1 || !errors.Is(&f.Warnings[0], midi.ErrTruncated) {
+		t.Errorf("warnings = %v; want one %v", f.Warnings, midi.ErrTruncated)
+	}
+	notes := f.Tracks[0].Notes
+	if len(notes) != 1 || len(f.Tracks[0].Unclosed) != 0 {
+		t.Fatalf("track has %d notes and %d unclosed; want 1 and 0", len(notes), len(f.Tracks[0].Unclosed))
+	}
+	if notes[0].StartTime != 0 || notes[0].Duration != 480 {
+		t.Errorf("closed note = %+v; want it to last until End of Track", notes[0])
+	}
+}
+
+func Test_HugeLengths(t *testing.T) {
+	// A 30 byte file claiming a track of 4 GB holding a meta event of 256 MB
+	data := []byte(header(1) + "MTrk\xff\xff\xff\xff" + "\x00\xff\x01\xff\xff\xff\x7fx")
+	if len(data) != 30 {
+		t.Fatalf("test file is %d bytes; want 30", len(data))
+	}
+
+	for _, lenient := range []bool{false, true} {
+		f := midi.MidiFile{Lenient: lenient}
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		ok, err := f.ParseBytes(data)
+		runtime.ReadMemStats(&after)
+
+		// Memory only grows with the bytes in the file
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+			t.Errorf("lenient %v: parsing allocated %d bytes", lenient, allocated)
+		}
+
+		if !lenient {
+			if ok || !errors.Is(err, midi.ErrTruncated) {
+				t.Errorf("ParseBytes() = %v, %v; want false, %v", ok, err, midi.ErrTruncated)
+			}
+			continue
+		}
+
+		// Lenient mode keeps the empty track and reports what was cut off
+		if err != nil {
+			t.Fatalf("lenient ParseBytes() = %v", err)
+		}
+		if len(f.Warnings) == 0 || !errors.Is(&f.Warnings[0], midi.ErrTruncated) {
+			t.Errorf("lenient Warnings = %v; want %v", f.Warnings, midi.ErrTruncated)
+		}
+	}
+}
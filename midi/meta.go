@@ -0,0 +1,182 @@
+package midi
+
+import "sort"
+
+// TimeSignature type used to hold the data of a time signature meta event
+type TimeSignature struct {
+	Numerator               byte `json:"numerator"`
+	Denominator             byte `json:"denominator"`
+	ClocksPerClick          byte `json:"clocksPerClick"`
+	ThirtySecondsPerQuarter byte `json:"thirtySecondsPerQuarter"`
+}
+
+// KeySignature type used to hold the data of a key signature meta event
+type KeySignature struct {
+	Sharps int8 `json:"sharps"` // Negative for flats
+	Minor  bool `json:"minor"`
+}
+
+// SMPTEOffset type used to hold the data of a SMPTE offset meta event
+type SMPTEOffset struct {
+	Hours            byte `json:"hours"`
+	Minutes          byte `json:"minutes"`
+	Seconds          byte `json:"seconds"`
+	Frames           byte `json:"frames"`
+	FractionalFrames byte `json:"fractionalFrames"`
+}
+
+// MetaEvent type used to hold a meta event from a track. The raw data is always
+// kept, and the field matching the event's type is decoded from it
+type MetaEvent struct {
+	Type byte   `json:"type"`
+	Tick int32  `json:"tick"` // Absolute tick of the event in its track
+	Data []byte `json:"data"`
+
+	Text           string         `json:"text,omitempty"`           // Text, copyright, names, lyrics, markers and cue points
+	SequenceNumber int            `json:"sequenceNumber,omitempty"` // MetaSequence
+	Channel        byte           `json:"channel,omitempty"`        // MetaChannelPrefix
+	Tempo          int32          `json:"tempo,omitempty"`          // MetaSetTempo, in microseconds per quarter note
+	SMPTEOffset    *SMPTEOffset   `json:"smpteOffset,omitempty"`
+	TimeSignature  *TimeSignature `json:"timeSignature,omitempty"`
+	KeySignature   *KeySignature  `json:"keySignature,omitempty"`
+}
+
+// IsText returns true if the event holds text
+func (e MetaEvent) IsText() bool {
+	return e.Type >= MetaText && e.Type <= MetaCuePoint
+}
+
+// decodeMeta creates a MetaEvent from the type and data of a meta event
+func decodeMeta(nType byte, tick int32, data []byte) MetaEvent {
+	e := MetaEvent{Type: nType, Tick: tick, Data: data}
+
+	switch nType {
+	case MetaSequence:
+		if len(data) == 2 {
+			e.SequenceNumber = int(data[0])<<8 | int(data[1])
+		}
+
+	case MetaText, MetaCopyright, MetaTrackName, MetaInstrumentName, MetaLyrics, MetaMarker, MetaCuePoint:
+		e.Text = string(data)
+
+	case MetaChannelPrefix:
+		if len(data) == 1 {
+			e.Channel = data[0]
+		}
+
+	case MetaSetTempo:
+		if len(data) == 3 {
+			e.Tempo = int32(data[0])<<16 | int32(data[1])<<8 | int32(data[2])
+		}
+
+	case MetaSMPTEOffset:
+		if len(data) == 5 {
+			e.SMPTEOffset = &SMPTEOffset{data[0], data[1], data[2], data[3], data[4]}
+		}
+
+	case MetaTimeSignature:
+		if len(data) == 4 && data[1] < 8 {
+			e.TimeSignature = &TimeSignature{data[0], 1 << data[1], data[2], data[3]}
+		}
+
+	case MetaKeySignature:
+		if len(data) == 2 {
+			e.KeySignature = &KeySignature{int8(data[0]), data[1] == 1}
+		}
+	}
+
+	return e
+}
+
+// sameFields returns true if the decoded fields of the two events are equal
+func sameFields(a, b MetaEvent) bool {
+	if a.Text != b.Text || a.SequenceNumber != b.SequenceNumber || a.Channel != b.Channel || a.Tempo != b.Tempo {
+		return false
+	}
+
+	if (a.SMPTEOffset == nil) != (b.SMPTEOffset == nil) || (a.SMPTEOffset != nil && *a.SMPTEOffset != *b.SMPTEOffset) {
+		return false
+	}
+	if (a.TimeSignature == nil) != (b.TimeSignature == nil) || (a.TimeSignature != nil && *a.TimeSignature != *b.TimeSignature) {
+		return false
+	}
+
+	return (a.KeySignature == nil) == (b.KeySignature == nil) && (a.KeySignature == nil || *a.KeySignature == *b.KeySignature)
+}
+
+// encode returns the data of the event. The raw data is used when the event's
+// fields still match it, otherwise the data is encoded from the field matching
+// the event's type
+func (e MetaEvent) encode() []byte {
+	if e.Data != nil && sameFields(decodeMeta(e.Type, e.Tick, e.Data), e) {
+		return e.Data
+	}
+
+	switch e.Type {
+	case MetaSequence:
+		return []byte{byte(e.SequenceNumber >> 8), byte(e.SequenceNumber)}
+
+	case MetaText, MetaCopyright, MetaTrackName, MetaInstrumentName, MetaLyrics, MetaMarker, MetaCuePoint:
+		return []byte(e.Text)
+
+	case MetaChannelPrefix:
+		return []byte{e.Channel}
+
+	case MetaSetTempo:
+		return []byte{byte(e.Tempo >> 16), byte(e.Tempo >> 8), byte(e.Tempo)}
+
+	case MetaSMPTEOffset:
+		if s := e.SMPTEOffset; s != nil {
+			return []byte{s.Hours, s.Minutes, s.Seconds, s.Frames, s.FractionalFrames}
+		}
+
+	case MetaTimeSignature:
+		if ts := e.TimeSignature; ts != nil {
+			// The denominator is stored as a power of two
+			var power byte
+			for 1<<power < int(ts.Denominator) {
+				power++
+			}
+			return []byte{ts.Numerator, power, ts.ClocksPerClick, ts.ThirtySecondsPerQuarter}
+		}
+
+	case MetaKeySignature:
+		if ks := e.KeySignature; ks != nil {
+			var minor byte
+			if ks.Minor {
+				minor = 1
+			}
+			return []byte{byte(ks.Sharps), minor}
+		}
+	}
+
+	return []byte{}
+}
+
+// MetaOfType returns the meta events of the given type in the track
+func (t *MidiTrack) MetaOfType(nType byte) []MetaEvent {
+	var events []MetaEvent
+
+	for _, event := range t.Meta {
+		if event.Type == nType {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// MetaOfType returns the meta events of the given type from every track, ordered
+// by tick
+func (f *MidiFile) MetaOfType(nType byte) []MetaEvent {
+	var events []MetaEvent
+
+	for i := range f.Tracks {
+		events = append(events, f.Tracks[i].MetaOfType(nType)...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Tick < events[j].Tick
+	})
+
+	return events
+}
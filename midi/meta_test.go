@@ -0,0 +1,78 @@
+package midi_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_MetaEvents(t *testing.T) {
+	track := "\x00\xff\x00\x02\x01\x02" + // Sequence number 258
+		"\x00\xff\x01\x05Title" +
+		"\x10\xff\x05\x03La " +
+		"\x10\xff\x06\x06Verse1" +
+		"\x00\xff\x20\x01\x09" +
+		"\x00\xff\x51\x03\x07\xa1\x20" +
+		"\x00\xff\x54\x05\x01\x02\x03\x04\x05" +
+		"\x00\xff\x58\x04\x06\x03\x18\x08" +
+		"\x00\xff\x59\x02\xfe\x01" +
+		"\x20\xff\x7f\x03\x00\x00\x41"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(1, 96, track)); err != nil {
+		t.Fatal(err)
+	}
+
+	meta := f.Tracks[0].Meta
+	if len(meta) != 10 {
+		t.Fatalf("got %d meta events; want 10", len(meta))
+	}
+
+	if meta[0].SequenceNumber != 258 {
+		t.Errorf("sequence number = %d; want 258", meta[0].SequenceNumber)
+	}
+	if meta[1].Text != "Title" || !meta[1].IsText() {
+		t.Errorf("text = %q; want Title", meta[1].Text)
+	}
+	if meta[2].Text != "La " || meta[2].Tick != 16 {
+		t.Errorf("lyric = %q at %d; want \"La \" at 16", meta[2].Text, meta[2].Tick)
+	}
+	if meta[3].Text != "Verse1" || meta[3].Tick != 32 {
+		t.Errorf("marker = %q at %d; want Verse1 at 32", meta[3].Text, meta[3].Tick)
+	}
+	if meta[4].Channel != 9 {
+		t.Errorf("channel prefix = %d; want 9", meta[4].Channel)
+	}
+	if meta[5].Tempo != 500000 {
+		t.Errorf("tempo = %d; want 500000", meta[5].Tempo)
+	}
+	if want := (midi.SMPTEOffset{1, 2, 3, 4, 5}); meta[6].SMPTEOffset == nil || *meta[6].SMPTEOffset != want {
+		t.Errorf("SMPTE offset = %v; want %v", meta[6].SMPTEOffset, want)
+	}
+	if want := (midi.TimeSignature{6, 8, 24, 8}); meta[7].TimeSignature == nil || *meta[7].TimeSignature != want {
+		t.Errorf("time signature = %v; want %v", meta[7].TimeSignature, want)
+	}
+	if want := (midi.KeySignature{-2, true}); meta[8].KeySignature == nil || *meta[8].KeySignature != want {
+		t.Errorf("key signature = %v; want %v", meta[8].KeySignature, want)
+	}
+	if !reflect.DeepEqual(meta[9].Data, []byte{0, 0, 0x41}) || meta[9].Tick != 64 {
+		t.Errorf("sequencer specific = %v at %d", meta[9].Data, meta[9].Tick)
+	}
+}
+
+func Test_MetaOfType(t *testing.T) {
+	var f midi.MidiFile
+	data := smf(1, 96, "\x00\xff\x05\x02Do\x20\xff\x05\x02Mi", "\x10\xff\x05\x02Re")
+	if _, err := f.ParseBytes(data); err != nil {
+		t.Fatal(err)
+	}
+
+	var lyrics []string
+	for _, event := range f.MetaOfType(midi.MetaLyrics) {
+		lyrics = append(lyrics, event.Text)
+	}
+	if want := []string{"Do", "Re", "Mi"}; !reflect.DeepEqual(lyrics, want) {
+		t.Errorf("lyrics = %v; want %v", lyrics, want)
+	}
+}
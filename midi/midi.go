@@ -2,9 +2,10 @@ package midi
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
-	"log"
 	"os"
 )
 
@@ -14,6 +15,11 @@ const (
 	Max_Note = 64
 	Min_Note = 64
 
+	// For file formats
+	FormatSingleTrack   = 0 // One track holding every channel
+	FormatMultiTrack    = 1 // Simultaneous tracks sharing the first track's tempo map
+	FormatMultiSequence = 2 // Independent single track sequences
+
 	// For events
 	VoiceNoteOff         = 0x80
 	VoiceNoteOn          = 0x90
@@ -42,16 +48,9 @@ const (
 	MetaSequencerSpecific = 0x7F
 )
 
-// MidiEvent type used to hold information from an event
-type MidiEvent struct {
-	Name      string `json:"name"`
-	Key       byte   `json:"key"`
-	Velocity  byte   `json:"velocity"`
-	DeltaTick int32  `json:"deltaTick"`
-}
-
 // MidiNote type used to hold information from a note
 type MidiNote struct {
+	Channel   byte  `json:"channel"`
 	Key       byte  `json:"key"`
 	Velocity  byte  `json:"velocity"`
 	StartTime int32 `json:"startTime"`
@@ -60,615 +59,649 @@ type MidiNote struct {
 
 // MidiTrack type used to hold information from a track
 type MidiTrack struct {
-	Name       string      `json:"name"`
-	Instrument string      `json:"instrument"`
-	Min        byte        `json:"min"`
-	Max        byte        `json:"max"`
-	Events     []MidiEvent `json:"events"`
-	Notes      []MidiNote  `json:"notes"`
+	Name       string               `json:"name"`
+	Instrument string               `json:"instrument"`
+	Min        byte                 `json:"min"`
+	Max        byte                 `json:"max"`
+	Events     []MidiEvent          `json:"events"`
+	Notes      []MidiNote           `json:"notes"`
+	Unclosed   []MidiNote           `json:"unclosed"` // Notes without a NoteOff, lasting until the end of the track
+	Programs   []ProgramChangeEvent `json:"programs"`
+	SysEx      []SysExEvent         `json:"sysex"`
+	EndTick    int32                `json:"endTick"` // Absolute tick of the End of Track event
+	Meta       []MetaEvent          `json:"meta"`
+}
+
+// Logger is the interface used to trace the parser. *log.Logger satisfies it
+type Logger interface {
+	Printf(format string, v ...interface{})
 }
 
 type MidiFile struct {
-	Tracks       []MidiTrack `json:"tracks"`
-	Tempo        int32       `json:"tempo"`
-	TimeDivision int16       `json:"timeDivision"`
-	reader       *bufio.Reader
-	atEof        bool
+	Format       uint16       `json:"format"`
+	Tracks       []MidiTrack  `json:"tracks"`
+	Tempo        int32        `json:"tempo"`
+	TimeDivision TimeDivision `json:"timeDivision"`
+	TempoMap     TempoMap     `json:"tempoMap"`
+
+	// Logger receives a debug trace of the parse. Parsing is silent when it is nil
+	Logger Logger `json:"-"`
+
+	// Pairing chooses how overlapping notes with the same channel and key are paired
+	Pairing PairingPolicy `json:"-"`
+
+	// Lenient repairs problems in malformed files instead of failing, and records
+	// what was repaired in Warnings
+	Lenient  bool         `json:"-"`
+	Warnings []ParseError `json:"warnings"`
+
+	// Pedals extends note durations while the sustain or sostenuto pedal is held
+	Pedals bool `json:"-"`
+
+	// RunningStatus makes Write omit repeated status bytes
+	RunningStatus bool `json:"-"`
+
+	reader     *bufio.Reader
+	offset     int64
+	chunkEnd   int64 // Offset of the end of the current chunk, or -1 outside of one
+	trackIndex int
 }
 
 // Helper functions
 
-// byteToInt32 converts an array of bytes to an 32 bit integer
-func byteToInt32(b []byte) int32 {
-	if len(b) != 4 {
-		return -1
+// byteToUint32 converts an array of big-endian bytes to an unsigned 32 bit integer
+func byteToUint32(b []byte) uint32 {
+	return binary.BigEndian.Uint32(b)
+}
+
+// byteToUint16 converts an array of big-endian bytes to an unsigned 16 bit integer
+func byteToUint16(b []byte) uint16 {
+	return binary.BigEndian.Uint16(b)
+}
+
+// isChunkId returns true if the bytes could be the ID of a chunk
+func isChunkId(b []byte) bool {
+	for _, c := range b {
+		if c < 0x20 || c > 0x7E {
+			return false
+		}
 	}
 
-	var n int32
-	n |= int32(b[0])
-	n |= int32(b[1])
-	n |= int32(b[2])
-	n |= int32(b[3])
+	return len(b) == 4
+}
 
-	return n
+// logf writes a message to the logger, if one is set
+func (f *MidiFile) logf(format string, v ...interface{}) {
+	if f.Logger != nil {
+		f.Logger.Printf(format, v...)
+	}
+}
+
+// newError creates a ParseError at the current position in the file
+func (f *MidiFile) newError(err error, msg string) error {
+	return &ParseError{Err: err, Msg: msg, Offset: f.offset, Track: f.trackIndex}
 }
 
-// byteToInt16 converts an array of bytes to an 16 bit integer
-func byteToInt16(b []byte) int16 {
-	if len(b) != 2 {
-		return -1
+// handleError converts an error from the reader into a ParseError
+func (f *MidiFile) handleError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return f.newError(ErrTruncated, "unexpected end of file")
 	}
 
-	var n int16
-	n |= int16(b[0])
-	n |= int16(b[1])
+	return f.newError(err, "")
+}
 
-	return n
+// checkChunk returns an error if reading 'n' bytes would pass the end of the
+// current chunk
+func (f *MidiFile) checkChunk(n int) error {
+	if f.chunkEnd >= 0 && f.offset+int64(n) > f.chunkEnd {
+		return f.newError(ErrTruncated, "event runs past the end of the track chunk")
+	}
+
+	return nil
 }
 
-// handleError handles all errors and checks specifically for an EOF error
-func (f *MidiFile) handleError(err error) {
-	if err == io.EOF {
-		return
+// peekByte returns the next byte from the reader without consuming it
+func (f *MidiFile) peekByte() (byte, error) {
+	if err := f.checkChunk(1); err != nil {
+		return 0, err
+	}
+
+	b, err := f.reader.Peek(1)
+	if err != nil {
+		return 0, f.handleError(err)
 	}
 
-	log.Fatal(err)
+	return b[0], nil
 }
 
-// readString reads 'n' bytes from the scanner
-func (f *MidiFile) readString(n int32) string {
-	s := ""
+// readByte reads a single byte from the reader
+func (f *MidiFile) readByte() (byte, error) {
+	if err := f.checkChunk(1); err != nil {
+		return 0, err
+	}
 
-	for i := 0; i < int(n); i++ {
-		c, err := f.reader.ReadByte()
-		if err != nil {
-			f.handleError(err)
-		}
-		s += string(c)
+	b, err := f.reader.ReadByte()
+	if err != nil {
+		return 0, f.handleError(err)
 	}
+	f.offset++
 
-	return s
+	return b, nil
 }
 
-// readValue reads a compressed MIDI value
-func (f *MidiFile) readValue() int32 {
-	var val int32
-	var b byte
+// readBytes reads 'n' bytes from the reader. The buffer grows with the bytes
+// actually read, so a huge declared length cannot allocate memory on its own
+func (f *MidiFile) readBytes(n int) ([]byte, error) {
+	if err := f.checkChunk(n); err != nil {
+		return nil, err
+	}
 
-	// Read the first byte
-	v, err := f.reader.ReadByte()
+	var buf bytes.Buffer
+	read, err := io.CopyN(&buf, f.reader, int64(n))
+	f.offset += read
 	if err != nil {
-		f.handleError(err)
+		return nil, f.handleError(err)
 	}
-	val = int32(v)
 
-	// Check if more bytes need reading
-	if val > 127 {
-		// Extract the bottom 7 bits of the read byte
-		val &= 127
+	if buf.Len() == 0 {
+		return []byte{}, nil
+	}
 
-		// Keep reading bytes until the compression has stopped
-		b, err = f.reader.ReadByte()
-		if err != nil {
-			f.handleError(err)
+	return buf.Bytes(), nil
+}
+
+// skipBytes discards 'n' bytes from the reader
+func (f *MidiFile) skipBytes(n int64) error {
+	for n > 0 {
+		// Discard in steps so huge lengths do not overflow an int
+		step := n
+		if step > 1<<20 {
+			step = 1 << 20
 		}
-		for b > 127 {
-			// Read the next byte
-			b, err = f.reader.ReadByte()
-			if err != nil {
-				f.handleError(err)
-			}
 
-			// Add the next byte to the value
-			val = (val << 7) | int32(b&127)
+		skipped, err := f.reader.Discard(int(step))
+		f.offset += int64(skipped)
+		n -= int64(skipped)
+		if err != nil {
+			return f.handleError(err)
 		}
 	}
 
-	return val
+	return nil
+}
+
+// byteReader adapts the MidiFile's reading helpers to an io.ByteReader
+type byteReader struct {
+	f *MidiFile
+}
+
+// ReadByte reads a single byte from the MidiFile
+func (r byteReader) ReadByte() (byte, error) {
+	return r.f.readByte()
+}
+
+// readValue reads a compressed MIDI value
+func (f *MidiFile) readValue() (int32, error) {
+	v, err := ReadVLQ(byteReader{f})
+	if err == ErrBadVLQ {
+		return 0, f.newError(ErrBadVLQ, "")
+	}
+
+	return int32(v), err
 }
 
-func (f *MidiFile) Parse(inputPath string) bool {
+// Parse parses the MIDI file at the given path. It returns true if the file was
+// parsed successfully. Otherwise, it returns false and an error describing the
+// problem, and the MidiFile is left empty. Problems with the file's contents are
+// reported as a *ParseError
+func (f *MidiFile) Parse(inputPath string) (bool, error) {
 	// Open the MIDI file as a stream
 	file, err := os.Open(inputPath)
 	if err != nil {
-		log.Fatal(err)
+		return false, err
 	}
 	defer file.Close()
 
+	return f.ParseReader(file)
+}
+
+// ParseBytes parses a MIDI file held in memory. See Parse for the meaning of the
+// return values
+func (f *MidiFile) ParseBytes(data []byte) (bool, error) {
+	return f.ParseReader(bytes.NewReader(data))
+}
+
+// ParseReader parses a MIDI file from the given reader. See Parse for the meaning
+// of the return values
+func (f *MidiFile) ParseReader(r io.Reader) (bool, error) {
 	// Create a scanner to read all of the bytes
-	f.reader = bufio.NewReader(file)
+	f.reader = bufio.NewReader(r)
+	defer func() { f.reader = nil }()
+
+	// Reset any previously parsed data
+	f.Format = 0
+	f.Tracks = nil
+	f.Tempo = 0
+	f.TimeDivision = TimeDivision{}
+	f.TempoMap = TempoMap{}
+	f.Warnings = nil
+	f.offset = 0
+	f.chunkEnd = -1
+	f.trackIndex = -1
+
+	if err := f.parse(); err != nil {
+		f.Format = 0
+		f.Tracks = nil
+		f.Tempo = 0
+		f.TimeDivision = TimeDivision{}
+		f.TempoMap = TempoMap{}
+		f.Warnings = nil
+		return false, err
+	}
+
+	return true, nil
+}
 
+// parse reads the header and track chunks from the reader
+func (f *MidiFile) parse() error {
 	// Filler variables to save memory
 	var b []byte
+	var err error
 
 	// Read the MIDI Header
-	fmt.Println("Starting parse")
+	f.logf("Starting parse")
+
+	// Unwrap RIFF RMID files
+	if err := f.unwrapRIFF(); err != nil {
+		return err
+	}
 
 	// Read the File ID
-	b, err = f.reader.Peek(4)
+	b, err = f.readBytes(4)
 	if err != nil {
-		f.handleError(err)
+		return err
 	}
 	fileId := string(b)
 	if fileId != "MThd" {
-		log.Fatal("File ID is not 'MThd', aborting!")
-	}
-	_, err = f.reader.Discard(4)
-	if err != nil {
-		f.handleError(err)
+		f.offset -= 4
+		return f.newError(ErrBadHeader, "file ID is not 'MThd'")
 	}
 
-	// Read the header length
-	b, err = f.reader.Peek(4)
+	// Read the header length. Any data after the six known bytes is skipped
+	b, err = f.readBytes(4)
 	if err != nil {
-		f.handleError(err)
+		return err
 	}
-	headerLength := byteToInt32(b)
-	if headerLength != 6 {
-		log.Fatal("Header length is not '6', aborting!")
-	}
-	_, err = f.reader.Discard(4)
-	if err != nil {
-		f.handleError(err)
+	headerLength := byteToUint32(b)
+	if headerLength < 6 {
+		f.offset -= 4
+		return f.newError(ErrBadHeader, "header length is less than '6'")
 	}
 
 	// Read the format type
-	b, err = f.reader.Peek(2)
+	b, err = f.readBytes(2)
 	if err != nil {
-		f.handleError(err)
+		return err
 	}
-	format := byteToInt16(b)
-	_, err = f.reader.Discard(2)
-	if err != nil {
-		f.handleError(err)
+	f.Format = byteToUint16(b)
+	if f.Format > FormatMultiSequence {
+		if !f.Lenient {
+			f.offset -= 2
+			return f.newError(ErrBadHeader, "format "+fmt.Sprint(f.Format)+" is not 0, 1 or 2")
+		}
+		f.warn(ErrBadHeader, "format "+fmt.Sprint(f.Format)+" read as format 1")
+		f.Format = FormatMultiTrack
 	}
 
 	// Read the number of tracks
-	b, err = f.reader.Peek(2)
-	if err != nil {
-		f.handleError(err)
-	}
-	trackNumber := byteToInt16(b)
-	_, err = f.reader.Discard(2)
+	b, err = f.readBytes(2)
 	if err != nil {
-		f.handleError(err)
+		return err
 	}
+	trackNumber := byteToUint16(b)
 
 	// Read the time division
-	b, err = f.reader.Peek(2)
+	b, err = f.readBytes(2)
 	if err != nil {
-		f.handleError(err)
+		return err
 	}
-	f.TimeDivision = byteToInt16(b)
-	_, err = f.reader.Discard(2)
-	if err != nil {
-		f.handleError(err)
+	f.TimeDivision = decodeTimeDivision(b)
+	if !f.TimeDivision.valid() {
+		if !f.Lenient {
+			f.offset -= 2
+			return f.newError(ErrBadHeader, "time division "+f.TimeDivision.String()+" is not valid")
+		}
+		f.warn(ErrBadHeader, "time division "+f.TimeDivision.String()+" read as "+fmt.Sprint(DefaultTicksPerQuarter)+" ticks per quarter note")
+		f.TimeDivision = TimeDivision{TicksPerQuarter: DefaultTicksPerQuarter}
 	}
 
-	fmt.Println("Parsed file id:", fileId)
-	fmt.Println("Parsed header length:", headerLength)
-	fmt.Println("Parsed format number:", format)
-	fmt.Println("Parsed track number:", trackNumber)
-	fmt.Println("Parsed time division:", f.TimeDivision)
-
-	// Read the track chunks
-	for trackIndex := 0; trackIndex < int(trackNumber); trackIndex++ {
-		fmt.Println("========== Starting track", trackIndex)
+	if err := f.skipBytes(int64(headerLength) - 6); err != nil {
+		return err
+	}
 
-		// Add the track to the list of tracks
-		var track MidiTrack
-		track.Min = 64
-		track.Max = 64
-		f.Tracks = append(f.Tracks, track)
+	f.logf("Parsed file id: %s", fileId)
+	f.logf("Parsed header length: %d", headerLength)
+	f.logf("Parsed format number: %d", f.Format)
+	f.logf("Parsed track number: %d", trackNumber)
+	f.logf("Parsed time division: %v", f.TimeDivision)
+
+	// Read the track chunks. Anything after the last track is ignored
+	for trackIndex := 0; trackIndex < int(trackNumber); {
+		f.trackIndex = trackIndex
+
+		// In lenient mode, stop at the end of the file
+		if f.Lenient {
+			if _, err := f.reader.Peek(1); err == io.EOF {
+				f.warn(ErrTruncated, "file ends after "+fmt.Sprint(trackIndex)+" of "+fmt.Sprint(trackNumber)+" tracks")
+				break
+			}
+		}
 
-		// Read the track header
+		// Read the chunk header, checking it before it is consumed
 		b, err = f.reader.Peek(4)
 		if err != nil {
-			f.handleError(err)
+			return f.handleError(err)
 		}
-		trackId := string(b)
-		if trackId != "MTrk" {
-			log.Fatal("Track ID of track " + fmt.Sprint(trackIndex) + " is not `MTrk`, aborting!")
-		}
-		_, err = f.reader.Discard(4)
-		if err != nil {
-			f.handleError(err)
+		chunkId := string(b)
+		if !isChunkId(b) {
+			if !f.Lenient {
+				return f.newError(ErrBadTrackHeader, "chunk ID "+fmt.Sprintf("%q", chunkId)+" is not valid")
+			}
+
+			// Look for the next track instead
+			start := f.offset
+			found, err := f.scanForTrack(-1)
+			if err != nil {
+				return err
+			}
+			if !found {
+				f.warn(ErrBadTrackHeader, "no track found after invalid chunk ID "+fmt.Sprintf("%q", chunkId))
+				break
+			}
+			f.warn(ErrBadTrackHeader, "skipped "+fmt.Sprint(f.offset-start)+" bytes of invalid data to the next track")
+			continue
 		}
 
-		// Read the track length
-		b, err = f.reader.Peek(4)
-		if err != nil {
-			f.handleError(err)
+		if err := f.skipBytes(4); err != nil {
+			return err
 		}
-		trackLength := byteToInt32(b)
-		_, err = f.reader.Discard(4)
+
+		// Read the chunk length
+		b, err = f.readBytes(4)
 		if err != nil {
-			f.handleError(err)
+			return err
 		}
+		chunkLength := byteToUint32(b)
 
-		fmt.Println("Parsed track ID:", trackId)
-		fmt.Println("Parsed track length:", trackLength)
+		// Skip chunks of unknown types
+		if chunkId != "MTrk" {
+			f.logf("Skipping %s chunk of length %d", chunkId, chunkLength)
+			if err := f.skipBytes(int64(chunkLength)); err != nil {
+				return err
+			}
+			continue
+		}
 
-		// Read the rest of the track data
-		var previousStatus byte
+		f.logf("========== Starting track %d", trackIndex)
+		f.logf("Parsed track ID: %s", chunkId)
+		f.logf("Parsed track length: %d", chunkLength)
 
-		endOfTrack := false
-		f.atEof = false
-		for !f.atEof && !endOfTrack {
-			// Read the timecode from MIDI stream
-			statusTimeDelta := f.readValue()
+		// Add the track to the list of tracks
+		var track MidiTrack
+		track.Min = 64
+		track.Max = 64
+		f.Tracks = append(f.Tracks, track)
+		trackIndex++
 
-			// Read the first byte of the message, which may be the status byte
-			b, err := f.reader.Peek(1)
-			if err != nil {
-				f.handleError(err)
+		// Read the events, bounded by the length of the chunk
+		f.chunkEnd = f.offset + int64(chunkLength)
+		if !f.Lenient {
+			if err := f.parseTrack(trackIndex - 1); err != nil {
+				return err
 			}
-			status := b[0]
 
-			// If the status byte was not set (omitted for compression, revert to the previous byte. Otherwise, progress forward
-			if status < 0x80 {
-				status = previousStatus
-			} else {
-				_, err := f.reader.Discard(1)
-				if err != nil {
-					f.handleError(err)
-				}
+			// Skip any data left in the chunk after the End of Track event
+			remaining := f.chunkEnd - f.offset
+			f.chunkEnd = -1
+			if err := f.skipBytes(remaining); err != nil {
+				return err
 			}
+			continue
+		}
 
-			// Read and parse different event types
-			switch status & 0xF0 {
-			case VoiceNoteOff:
-				previousStatus = status
-
-				// Get the note id
-				noteId, err := f.reader.ReadByte()
-				if err != nil {
-					f.handleError(err)
-				}
+		// In lenient mode, the length only hints where the next track starts
+		if err := f.parseLenientTrack(trackIndex-1, f.chunkEnd); err != nil {
+			return err
+		}
+	}
+	f.trackIndex = -1
+
+	// Collect the tempo changes from every track. Each sequence of a format 2 file
+	// has its own tempo changes, so only the first sequence's are used
+	if f.Format == FormatMultiSequence && len(f.Tracks) > 0 {
+		f.TempoMap = newTempoMap(f.TimeDivision, f.Tracks[0].MetaOfType(MetaSetTempo))
+	} else {
+		f.TempoMap = newTempoMap(f.TimeDivision, f.MetaOfType(MetaSetTempo))
+	}
 
-				// Get the note velocity
-				noteVelocity, err := f.reader.ReadByte()
-				if err != nil {
-					f.handleError(err)
-				}
+	// Convert time events to notes
+	for index := range f.Tracks {
+		f.Tracks[index].collectPrograms()
+		f.Tracks[index].pairNotes(f.Pairing)
+		if f.Lenient {
+			f.closeUnclosed(index)
+		}
+		if f.Pedals {
+			f.Tracks[index].applyPedals()
+		}
+	}
 
-				// Create a new MidiEvent and add it to the current track
-				event := MidiEvent{"NoteOff", noteId, noteVelocity, statusTimeDelta}
-				f.Tracks[trackIndex].Events = append(f.Tracks[trackIndex].Events, event)
+	return nil
+}
 
-				fmt.Println("NoteOff added")
+// parseTrack reads the events of a single track until its End of Track event
+func (f *MidiFile) parseTrack(trackIndex int) error {
+	var previousStatus, channelStatus byte
+	var tick, eventTick int32
+
+	endOfTrack := false
+	for !endOfTrack {
+		// In lenient mode, a track without an End of Track event ends at the next track
+		if f.Lenient && f.atTrackHeader() {
+			f.warn(ErrTruncated, "track has no End of Track event")
+			return nil
+		}
 
-			case VoiceNoteOn:
-				previousStatus = status
+		// Read the timecode from MIDI stream
+		statusTimeDelta, err := f.readValue()
+		if err != nil {
+			return err
+		}
+		tick += statusTimeDelta
 
-				// Get the note id
-				noteId, err := f.reader.ReadByte()
-				if err != nil {
-					f.handleError(err)
-				}
+		// Read the first byte of the message, which may be the status byte
+		status, err := f.peekByte()
+		if err != nil {
+			return err
+		}
 
-				// Get the note velocity
-				noteVelocity, err := f.reader.ReadByte()
-				if err != nil {
-					f.handleError(err)
-				}
+		// If the status byte was not set (omitted for compression, revert to the previous byte. Otherwise, progress forward
+		if status < 0x80 {
+			status = previousStatus
 
-				// Create a new MidiEvent and add it to the current track
-				var event MidiEvent
-				if noteVelocity == 0 {
-					event = MidiEvent{"NoteOff", noteId, noteVelocity, statusTimeDelta}
-				} else {
-					event = MidiEvent{"NoteOn", noteId, noteVelocity, statusTimeDelta}
+			// Lenient mode lets running status continue after a meta or system exclusive event
+			if status == 0 && f.Lenient {
+				if channelStatus == 0 {
+					f.warn(ErrUnknownStatus, "skipped data byte without a status")
+					if _, err := f.readByte(); err != nil {
+						return err
+					}
+					continue
 				}
-				f.Tracks[trackIndex].Events = append(f.Tracks[trackIndex].Events, event)
 
-				fmt.Println("NoteOn added")
+				f.warn(ErrUnknownStatus, "running status continued after a meta or system exclusive event")
+				status = channelStatus
+			}
+		} else {
+			_, err := f.readByte()
+			if err != nil {
+				return err
+			}
+		}
 
-			case VoiceAftertouch:
-				previousStatus = status
+		// Read and parse different event types
+		switch status & 0xF0 {
+		case VoiceNoteOff, VoiceNoteOn, VoiceAftertouch, VoiceControlChange, VoiceProgramChange, VoiceChannelPressure, VoicePitchBend:
+			previousStatus = status
+			channelStatus = status
 
-				// Get the note id
-				_, err = f.reader.ReadByte()
-				if err != nil {
-					f.handleError(err)
-				}
+			// Get the data bytes of the event. Its delta is measured from the previous channel event
+			event := MidiEvent{Kind: EventKind(status & 0xF0), Channel: status & 0x0F, DeltaTick: tick - eventTick}
+			eventTick = tick
+			data, err := f.readBytes(event.Kind.dataLength())
+			if err != nil {
+				return err
+			}
+			copy(event.Data[:], data)
 
-				// Get the note velocity
-				_, err = f.reader.ReadByte()
-				if err != nil {
-					f.handleError(err)
-				}
+			// A NoteOn with no velocity is a NoteOff
+			if event.Kind == KindNoteOn && event.Data[1] == 0 {
+				event.Kind = KindNoteOff
+			}
 
-				// Create a new MidiEvent and add it to the current track
-				var event MidiEvent
-				event.Name = "Other"
-				f.Tracks[trackIndex].Events = append(f.Tracks[trackIndex].Events, event)
+			// Add the event to the current track
+			f.Tracks[trackIndex].Events = append(f.Tracks[trackIndex].Events, event)
+			f.logf("%v added", event.Kind)
 
-			case VoiceControlChange:
-				previousStatus = status
+		case SystemExclusive:
+			// System exclusive and meta events cancel running status
+			previousStatus = 0
 
-				// Get the control id
-				_, err = f.reader.ReadByte()
+			switch status {
+			case 0xFF:
+				endOfTrack, err = f.parseMeta(trackIndex, tick)
 				if err != nil {
-					f.handleError(err)
+					return err
 				}
 
-				// Get the control value
-				_, err = f.reader.ReadByte()
-				if err != nil {
-					f.handleError(err)
+			case 0xF0, 0xF7:
+				if err := f.parseSysEx(trackIndex, status, tick); err != nil {
+					return err
 				}
 
-				// Create a new MidiEvent and add it to the current track
-				var event MidiEvent
-				event.Name = "Other"
-				f.Tracks[trackIndex].Events = append(f.Tracks[trackIndex].Events, event)
+			default:
+				// System common and real time messages do not belong in a file
+				if !f.Lenient {
+					return f.newError(ErrUnknownStatus, "system message "+fmt.Sprintf("%#x", status))
+				}
+				f.warn(ErrUnknownStatus, "skipped system message "+fmt.Sprintf("%#x", status))
+			}
 
-			case VoiceProgramChange:
-				previousStatus = status
+		default:
+			return f.newError(ErrUnknownStatus, "status byte "+fmt.Sprint(status))
+		}
 
-				// Get the program id
-				_, err = f.reader.ReadByte()
-				if err != nil {
-					f.handleError(err)
-				}
+		// The track ends with its last event, normally End of Track
+		f.Tracks[trackIndex].EndTick = tick
+	}
 
-				// Create a new MidiEvent and add it to the current track
-				var event MidiEvent
-				event.Name = "Other"
-				f.Tracks[trackIndex].Events = append(f.Tracks[trackIndex].Events, event)
+	return nil
+}
 
-			case VoiceChannelPressure:
-				previousStatus = status
+// parseMeta reads a meta event, returning true if the event ended the track
+func (f *MidiFile) parseMeta(trackIndex int, tick int32) (bool, error) {
+	// Get the length and type of the event
+	nType, err := f.readByte()
+	if err != nil {
+		return false, err
+	}
+	length, err := f.readValue()
+	if err != nil {
+		return false, err
+	}
+	if length < 0 {
+		return false, f.newError(ErrTruncated, "negative length "+fmt.Sprint(length))
+	}
 
-				// Get the channel pressure
-				_, err = f.reader.ReadByte()
-				if err != nil {
-					f.handleError(err)
-				}
+	// Get the data of the event
+	data, err := f.readBytes(int(length))
+	if err != nil {
+		return false, err
+	}
+
+	if nType == MetaEndOfTrack {
+		f.logf("End of track")
+		return true, nil
+	}
 
-				// Create a new MidiEvent and add it to the current track
-				var event MidiEvent
-				event.Name = "Other"
-				f.Tracks[trackIndex].Events = append(f.Tracks[trackIndex].Events, event)
+	// Add the meta event to the current track
+	event := decodeMeta(nType, tick, data)
+	f.Tracks[trackIndex].Meta = append(f.Tracks[trackIndex].Meta, event)
 
-			case VoicePitchBend:
-				previousStatus = status
+	switch nType {
+	case MetaSequence:
+		f.logf("Sequence number: %d", event.SequenceNumber)
 
-				// Get the LS7B
-				_, err = f.reader.ReadByte()
-				if err != nil {
-					f.handleError(err)
-				}
+	case MetaText:
+		f.logf("Text: %s", event.Text)
 
-				// Get the MS7B
-				_, err = f.reader.ReadByte()
-				if err != nil {
-					f.handleError(err)
-				}
+	case MetaCopyright:
+		f.logf("Copyright: %s", event.Text)
 
-				// Create a new MidiEvent and add it to the current track
-				var event MidiEvent
-				event.Name = "Other"
-				f.Tracks[trackIndex].Events = append(f.Tracks[trackIndex].Events, event)
+	case MetaTrackName:
+		f.Tracks[trackIndex].Name = event.Text
+		f.logf("Track name: %s", event.Text)
 
-			case SystemExclusive:
-				previousStatus = 0
+	case MetaInstrumentName:
+		f.Tracks[trackIndex].Instrument = event.Text
+		f.logf("Instrument name: %s", event.Text)
 
-				// If the event is a meta message
-				if status == 0xFF {
+	case MetaLyrics:
+		f.logf("Lyrics: %s", event.Text)
 
-					// Get the length and type of the event
-					nType, err := f.reader.ReadByte()
-					if err != nil {
-						f.handleError(err)
-					}
-					length := f.readValue()
-
-					switch nType {
-					case MetaSequence:
-						num1, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						num2, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-						fmt.Println("Sequence number: " + fmt.Sprint(num1) + fmt.Sprint(num2))
-
-					case MetaText:
-						fmt.Println("Text: " + f.readString(length))
-
-					case MetaCopyright:
-						fmt.Println("Copyright: " + f.readString(length))
-
-					case MetaTrackName:
-						f.Tracks[trackIndex].Name = f.readString(length)
-						fmt.Println("Track name: " + f.Tracks[trackIndex].Name)
-
-					case MetaInstrumentName:
-						f.Tracks[trackIndex].Instrument = f.readString(length)
-						fmt.Println("Instrument name: " + f.Tracks[trackIndex].Instrument)
-
-					case MetaLyrics:
-						fmt.Println("Lyrics: " + f.readString(length))
-
-					case MetaMarker:
-						fmt.Println("Marker: " + f.readString(length))
-
-					case MetaCuePoint:
-						fmt.Println("Cue: " + f.readString(length))
-
-					case MetaChannelPrefix:
-						fmt.Println("Prefix: " + f.readString(length))
-
-					case MetaEndOfTrack:
-						fmt.Println("End of track")
-						endOfTrack = true
-
-					case MetaSetTempo:
-						// Tempo is in microseconds per quarter note
-						if f.Tempo == 0 {
-							// Get the three values for the tempo
-							t1, err := f.reader.ReadByte()
-							if err != nil {
-								f.handleError(err)
-							}
-
-							t2, err := f.reader.ReadByte()
-							if err != nil {
-								f.handleError(err)
-							}
-
-							t3, err := f.reader.ReadByte()
-							if err != nil {
-								f.handleError(err)
-							}
-
-							// Set the tempo
-							f.Tempo |= int32(t1) << 16
-							f.Tempo |= int32(t2) << 8
-							f.Tempo |= int32(t3) << 0
-
-							// Display the tempo (and bpm)
-							bpm := (60000000 / f.Tempo)
-
-							fmt.Println("Tempo: " + fmt.Sprint(f.Tempo) + " (BPM: " + fmt.Sprint(bpm) + ")")
-						}
-
-					case MetaSMPTEOffset:
-						// Get the attributes
-						h, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						m, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						s, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						fr, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						ff, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						// Display the attributes
-						fmt.Println("SMPTE: H:" + fmt.Sprint(h) + " M:" + fmt.Sprint(m) + " S:" + fmt.Sprint(s) + " FR:" + fmt.Sprint(fr) + "FF:" + fmt.Sprint(ff))
-
-					case MetaTimeSignature:
-						// Get the attributes
-						ts1, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						ts2, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						cpt, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						per24c, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						// Display the attributes
-						fmt.Println("Time signature: " + fmt.Sprint(ts1) + " / " + fmt.Sprint(2<<ts2))
-						fmt.Println("Clocks per tick: " + fmt.Sprint(cpt))
-						fmt.Println("32 per 24 clocks: " + fmt.Sprint(per24c))
-
-					case MetaKeySignature:
-						// Get the attributes
-						keySignature, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						minorKey, err := f.reader.ReadByte()
-						if err != nil {
-							f.handleError(err)
-						}
-
-						// Display the attributes
-						fmt.Println("Key signature: " + fmt.Sprint(keySignature))
-						fmt.Println("Minor key: " + fmt.Sprint(minorKey))
-
-					case MetaSequencerSpecific:
-						fmt.Println("Sequencer specifics: " + f.readString(length))
-
-					default:
-						fmt.Println("Warning! Unrecognized MetaEvent " + fmt.Sprint(nType))
-					}
-				}
+	case MetaMarker:
+		f.logf("Marker: %s", event.Text)
 
-				if status == 0xF0 {
-					fmt.Println("System exclusive begin: " + f.readString(f.readValue()))
-				} else if status == 0xF7 {
-					fmt.Println("System exclusive end: " + f.readString(f.readValue()))
-				}
+	case MetaCuePoint:
+		f.logf("Cue: %s", event.Text)
 
-			default:
-				fmt.Println("Unrecognized status byte: " + fmt.Sprint(status))
+	case MetaChannelPrefix:
+		f.logf("Prefix: %d", event.Channel)
 
+	case MetaSetTempo:
+		// Tempo is in microseconds per quarter note. The first one is kept as the file's tempo
+		if event.Tempo != 0 {
+			if f.Tempo == 0 {
+				f.Tempo = event.Tempo
 			}
+			f.logf("Tempo: %d (BPM: %d)", event.Tempo, 60000000/event.Tempo)
 		}
-	}
 
-	// Convert time events to notes
-	for index, _ := range f.Tracks {
-		var notesBeingProcessed []MidiNote
-		var wallTime int32
-
-		for _, event := range f.Tracks[index].Events {
-			wallTime += event.DeltaTick
-
-			if event.Name == "NoteOn" {
-				// Add an 'NoteOn' to the processing notes
-				note := MidiNote{event.Key, event.Velocity, wallTime, 0}
-				notesBeingProcessed = append(notesBeingProcessed, note)
-			} else if event.Name == "NoteOff" {
-				// Remove an 'NoteOn' if it exists from processing notes
-				for i, note := range notesBeingProcessed {
-					if event.Key == note.Key {
-						// Set the note's duration and add it to the track
-						note.Duration = wallTime - note.StartTime
-						f.Tracks[index].Notes = append(f.Tracks[index].Notes, note)
-
-						// Change the min/max note of the track
-						if note.Key < f.Tracks[index].Min {
-							f.Tracks[index].Min = note.Key
-						}
-
-						if note.Key > f.Tracks[index].Max {
-							f.Tracks[index].Max = note.Key
-						}
-
-						if i < len(notesBeingProcessed) {
-							notesBeingProcessed = append(notesBeingProcessed[:i], notesBeingProcessed[i+1:]...)
-						}
-					}
-				}
-			}
+	case MetaSMPTEOffset:
+		if s := event.SMPTEOffset; s != nil {
+			f.logf("SMPTE: H:%d M:%d S:%d FR:%d FF:%d", s.Hours, s.Minutes, s.Seconds, s.Frames, s.FractionalFrames)
+		}
+
+	case MetaTimeSignature:
+		if ts := event.TimeSignature; ts != nil {
+			f.logf("Time signature: %d / %d", ts.Numerator, ts.Denominator)
+			f.logf("Clocks per tick: %d", ts.ClocksPerClick)
+			f.logf("32 per 24 clocks: %d", ts.ThirtySecondsPerQuarter)
+		}
+
+	case MetaKeySignature:
+		if ks := event.KeySignature; ks != nil {
+			f.logf("Key signature: %d", ks.Sharps)
+			f.logf("Minor key: %v", ks.Minor)
 		}
+
+	case MetaSequencerSpecific:
+		f.logf("Sequencer specifics: %v", data)
+
+	default:
+		f.logf("Warning! Unrecognized MetaEvent %d", nType)
 	}
 
-	return true
+	return false, nil
 }
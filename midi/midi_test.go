@@ -1,15 +1,41 @@
 package midi_test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
 	"testing"
 
-	"github.com/ethanbaker/midi-graphic"
+	"github.com/ethanbaker/midi-to-musicbox/midi"
 )
 
+// smf builds a Standard MIDI File with the given format, time division and raw
+// track data. An End of Track event is appended to every track
+func smf(format int, division int, tracks ...string) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("MThd")
+	b.Write([]byte{0, 0, 0, 6, byte(format >> 8), byte(format), byte(len(tracks) >> 8), byte(len(tracks)), byte(division >> 8), byte(division)})
+
+	for _, track := range tracks {
+		track += "\x00\xff\x2f\x00"
+		b.WriteString("MTrk")
+		b.Write([]byte{byte(len(track) >> 24), byte(len(track) >> 16), byte(len(track) >> 8), byte(len(track))})
+		b.WriteString(track)
+	}
+
+	return b.Bytes()
+}
+
 func Test_MidiFile(t *testing.T) {
 	var f midi.MidiFile
 
-	f.Parse("./testing/midi.mid")
+	ok, err := f.Parse("./testing/midi.mid")
+	if !ok || err != nil {
+		t.Fatalf("Parse() = %v, %v; want true, nil", ok, err)
+	}
 
 	/*
 		jsonString, err := json.Marshal(f)
@@ -19,3 +45,173 @@ func Test_MidiFile(t *testing.T) {
 		ioutil.WriteFile("output.json", jsonString, os.ModePerm)
 	*/
 }
+
+// recordLogger records every message written to it
+type recordLogger struct {
+	lines []string
+}
+
+func (l *recordLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func Test_ParseLogger(t *testing.T) {
+	var logger recordLogger
+	f := midi.MidiFile{Logger: &logger}
+
+	if _, err := f.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.lines) == 0 {
+		t.Fatal("Logger received no messages")
+	}
+	if logger.lines[0] != "Starting parse" {
+		t.Errorf("first message = %q; want %q", logger.lines[0], "Starting parse")
+	}
+}
+
+func Test_ParseMeta(t *testing.T) {
+	var f midi.MidiFile
+
+	if _, err := f.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+
+	meta := f.Tracks[0].Meta
+	types := []byte{midi.MetaCopyright, midi.MetaCuePoint, midi.MetaCuePoint, midi.MetaSetTempo, midi.MetaTimeSignature}
+	if len(meta) != len(types) {
+		t.Fatalf("track 0 has %d meta events; want %d", len(meta), len(types))
+	}
+	for i, event := range meta {
+		if event.Type != types[i] {
+			t.Errorf("meta event %d has type %#x; want %#x", i, event.Type, types[i])
+		}
+	}
+	if string(meta[0].Data) != "Copyright (c) xxxx Copyright Holder" {
+		t.Errorf("copyright = %q", meta[0].Data)
+	}
+	if f.Tracks[1].Name != "RightHand" || f.Tracks[2].Name != "LeftHand" {
+		t.Errorf("track names = %q, %q; want RightHand, LeftHand", f.Tracks[1].Name, f.Tracks[2].Name)
+	}
+}
+
+func Test_ParseSources(t *testing.T) {
+	data, err := ioutil.ReadFile("./testing/midi.mid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fromPath, fromReader, fromBytes midi.MidiFile
+	if _, err := fromPath.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fromReader.ParseReader(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fromBytes.ParseBytes(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fromPath.Tracks, fromReader.Tracks) {
+		t.Error("ParseReader() tracks differ from Parse()")
+	}
+	if !reflect.DeepEqual(fromPath.Tracks, fromBytes.Tracks) {
+		t.Error("ParseBytes() tracks differ from Parse()")
+	}
+
+	// Parsing again replaces the previous data instead of appending to it
+	if _, err := fromBytes.ParseBytes(data); err != nil {
+		t.Fatal(err)
+	}
+	if len(fromBytes.Tracks) != len(fromPath.Tracks) {
+		t.Errorf("reparse produced %d tracks; want %d", len(fromBytes.Tracks), len(fromPath.Tracks))
+	}
+}
+
+func Test_ParseChunks(t *testing.T) {
+	var b bytes.Buffer
+
+	// A header with two extra bytes, 300 tracks and a division above 255
+	b.WriteString("MThd\x00\x00\x00\x08\x00\x01\x01\x2c\x03\xc0\xaa\xbb")
+
+	// An unknown chunk before the tracks
+	b.WriteString("XFIH\x00\x00\x00\x03abc")
+
+	for i := 0; i < 300; i++ {
+		// A note, an End of Track event and data the parser should skip
+		b.WriteString("MTrk\x00\x00\x00\x0f\x00\x90\x40\x40\x10\x80\x40\x00\x00\xff\x2f\x00\xde\xad\xbe")
+	}
+
+	// Trailing garbage after the last track
+	b.WriteString("\x00\x01garbage")
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(b.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Tracks) != 300 {
+		t.Fatalf("parsed %d tracks; want 300", len(f.Tracks))
+	}
+	if f.TimeDivision.TicksPerQuarter != 960 {
+		t.Errorf("TicksPerQuarter = %d; want 960", f.TimeDivision.TicksPerQuarter)
+	}
+	for i, track := range f.Tracks {
+		if len(track.Notes) != 1 || track.Notes[0].Duration != 16 {
+			t.Fatalf("track %d notes = %v; want one note of 16 ticks", i, track.Notes)
+		}
+	}
+}
+
+func Test_ParseErrors(t *testing.T) {
+	valid, err := ioutil.ReadFile("./testing/midi.mid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		err    error
+		offset int64
+		track  int
+	}{
+		{"bad file id", []byte("RIFX\x00\x00\x00\x06\x00\x01\x00\x01\x00\x60"), midi.ErrBadHeader, 0, -1},
+		{"bad header length", []byte("MThd\x00\x00\x00\x05\x00\x01\x00\x01\x00\x60"), midi.ErrBadHeader, 4, -1},
+		{"truncated header", []byte("MThd\x00\x00\x00\x06\x00"), midi.ErrTruncated, 9, -1},
+		{"bad track id", append(append([]byte{}, valid[:14]...), "\x00\x01\x02\x03"...), midi.ErrBadTrackHeader, 14, 0},
+		{"truncated track", valid[:100], midi.ErrTruncated, 100, 0},
+		{"event past end of chunk", []byte("MThd\x00\x00\x00\x06\x00\x00\x00\x01\x00\x60MTrk\x00\x00\x00\x02\x00\x90\x40\x40\x00\xff\x2f\x00"), midi.ErrTruncated, 24, 0},
+		{"running status without status", []byte("MThd\x00\x00\x00\x06\x00\x00\x00\x01\x00\x60MTrk\x00\x00\x00\x04\x00\x40\x40\x00"), midi.ErrUnknownStatus, 23, 0},
+	}
+
+	for _, test := range tests {
+		var f midi.MidiFile
+		ok, err := f.ParseBytes(test.data)
+		if ok {
+			t.Errorf("%s: Parse() returned true", test.name)
+			continue
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: Parse() error = %v; want %v", test.name, err, test.err)
+			continue
+		}
+
+		var parseErr *midi.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: Parse() error is not a *ParseError", test.name)
+			continue
+		}
+		if parseErr.Offset != test.offset || parseErr.Track != test.track {
+			t.Errorf("%s: error at offset %d, track %d; want offset %d, track %d", test.name, parseErr.Offset, parseErr.Track, test.offset, test.track)
+		}
+		if len(f.Tracks) != 0 {
+			t.Errorf("%s: Tracks were not cleared after failure", test.name)
+		}
+	}
+
+	// A missing file is reported without panicking
+	var f midi.MidiFile
+	if ok, err := f.Parse("./testing/missing.mid"); ok || err == nil {
+		t.Errorf("Parse() of missing file = %v, %v; want false, error", ok, err)
+	}
+}
@@ -0,0 +1,114 @@
+package midi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MusicBoxSpec type used to describe a paper strip music box mechanism. Lengths
+// are in millimeters. Across the strip, the tines are laid out in comb order from
+// the top edge. Along the strip, notes are spaced by the paper speed, either per
+// second or per beat (a quarter note) when MillimetersPerBeat is set. Each note
+// is marked with a hole of the given diameter and shape
+type MusicBoxSpec struct {
+	Name  string  `json:"name"`
+	Notes []byte  `json:"notes"` // MIDI keys of the tines in comb order
+	Pitch float64 `json:"pitch"` // Distance between neighbouring tines
+	Width float64 `json:"width"` // Width of the paper strip
+
+	Margin  float64 `json:"margin"`  // Distance from the top edge of the strip to the first tine
+	LeadIn  float64 `json:"leadIn"`  // Blank paper before the first note
+	LeadOut float64 `json:"leadOut"` // Blank paper after the last note
+
+	HoleDiameter float64   `json:"holeDiameter"`
+	HoleShape    HoleShape `json:"holeShape"`
+
+	// Percussion punches notes on the percussion channel like any other note
+	Percussion bool `json:"percussion,omitempty"`
+
+	MillimetersPerSecond float64 `json:"millimetersPerSecond,omitempty"`
+	MillimetersPerBeat   float64 `json:"millimetersPerBeat,omitempty"`
+}
+
+// Presets for common commercial mechanisms
+var (
+	// MusicBox15 is a 15 note box playing two octaves of C major from C4
+	MusicBox15 = MusicBoxSpec{
+		Name:  "15 note",
+		Notes: []byte{60, 62, 64, 65, 67, 69, 71, 72, 74, 76, 77, 79, 81, 83, 84},
+		Pitch: 2, Width: 41, Margin: 6.5, LeadIn: 20, LeadOut: 20, HoleDiameter: 1.5,
+
+		MillimetersPerBeat: 8,
+	}
+
+	// MusicBox20 is a 20 note box from C4 to B6, adding the F sharps of the upper
+	// octaves and leaving out the lower E and F
+	MusicBox20 = MusicBoxSpec{
+		Name:  "20 note",
+		Notes: []byte{60, 62, 67, 69, 71, 72, 74, 76, 77, 78, 79, 81, 83, 84, 86, 88, 90, 91, 93, 95},
+		Pitch: 2, Width: 50, Margin: 6, LeadIn: 20, LeadOut: 20, HoleDiameter: 1.5,
+
+		MillimetersPerBeat: 8,
+	}
+
+	// MusicBox30 is a 30 note box from C3 to E6, chromatic from F4 to B5
+	MusicBox30 = MusicBoxSpec{
+		Name: "30 note",
+		Notes: []byte{48, 50, 55, 57, 59, 60, 62, 64, 65, 66, 67, 68, 69, 70, 71,
+			72, 73, 74, 75, 76, 77, 78, 79, 80, 81, 82, 83, 84, 86, 88},
+		Pitch: 2, Width: 70, Margin: 6, LeadIn: 20, LeadOut: 20, HoleDiameter: 1.5,
+
+		MillimetersPerBeat: 8,
+	}
+
+	// MusicBoxPresets lists every preset
+	MusicBoxPresets = []MusicBoxSpec{MusicBox15, MusicBox20, MusicBox30}
+)
+
+// Validate returns an error if the spec cannot be used to lay out a strip
+func (s MusicBoxSpec) Validate() error {
+	switch {
+	case len(s.Notes) == 0:
+		return errors.New("midi: music box has no tines")
+	case s.Pitch <= 0:
+		return errors.New("midi: music box tine pitch must be positive")
+	case s.HoleDiameter <= 0 || s.HoleDiameter > s.Pitch:
+		return errors.New("midi: music box hole diameter must be positive and no larger than the tine pitch")
+	case s.Margin < 0 || s.LeadIn < 0 || s.LeadOut < 0:
+		return errors.New("midi: music box margins cannot be negative")
+	case s.Margin+float64(len(s.Notes)-1)*s.Pitch > s.Width:
+		return fmt.Errorf("midi: music box tines do not fit on a %v mm strip", s.Width)
+	case s.MillimetersPerSecond <= 0 && s.MillimetersPerBeat <= 0:
+		return errors.New("midi: music box paper speed must be positive")
+	}
+
+	return nil
+}
+
+// Tine returns the index of the tine playing the given MIDI key. False is
+// returned if no tine plays it
+func (s MusicBoxSpec) Tine(key byte) (int, bool) {
+	for i, note := range s.Notes {
+		if note == key {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// TineOffset returns the distance from the top edge of the strip to the center
+// of the tine's track
+func (s MusicBoxSpec) TineOffset(tine int) float64 {
+	return s.Margin + float64(tine)*s.Pitch
+}
+
+// distance returns how far along the strip a time is, counted from the end of the
+// lead in. Beats are counted in quarter notes of the given length
+func (s MusicBoxSpec) distance(tick int32, seconds float64, ticksPerQuarter int) float64 {
+	if s.MillimetersPerBeat > 0 && ticksPerQuarter > 0 {
+		return float64(tick) / float64(ticksPerQuarter) * s.MillimetersPerBeat
+	}
+
+	return seconds * s.MillimetersPerSecond
+}
@@ -0,0 +1,67 @@
+package midi_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_MusicBoxPresets(t *testing.T) {
+	for _, spec := range midi.MusicBoxPresets {
+		if err := spec.Validate(); err != nil {
+			t.Errorf("%s: Validate() = %v", spec.Name, err)
+		}
+
+		// The tines must rise in pitch along the comb
+		for i := 1; i < len(spec.Notes); i++ {
+			if spec.Notes[i] <= spec.Notes[i-1] {
+				t.Errorf("%s: tine %d is not higher than tine %d", spec.Name, i, i-1)
+			}
+		}
+	}
+
+	for spec, tines := range map[*midi.MusicBoxSpec]int{&midi.MusicBox15: 15, &midi.MusicBox20: 20, &midi.MusicBox30: 30} {
+		if len(spec.Notes) != tines {
+			t.Errorf("%s has %d tines; want %d", spec.Name, len(spec.Notes), tines)
+		}
+	}
+}
+
+func Test_MusicBoxSpec(t *testing.T) {
+	spec := midi.MusicBoxSpec{Notes: []byte{60, 62, 64}, Pitch: 3, Width: 12, Margin: 2, HoleDiameter: 2, MillimetersPerSecond: 10}
+	if err := spec.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if tine, ok := spec.Tine(64); !ok || tine != 2 {
+		t.Errorf("Tine(64) = %d, %v; want 2, true", tine, ok)
+	}
+	if _, ok := spec.Tine(61); ok {
+		t.Error("Tine(61) found a tine for a missing note")
+	}
+	if offset := spec.TineOffset(2); offset != 8 {
+		t.Errorf("TineOffset(2) = %v; want 8", offset)
+	}
+
+	invalid := []midi.MusicBoxSpec{
+		{Pitch: 3, Width: 12, HoleDiameter: 2, MillimetersPerSecond: 10},
+		{Notes: []byte{60}, Width: 12, HoleDiameter: 2, MillimetersPerSecond: 10},
+		{Notes: []byte{60, 62, 64}, Pitch: 3, Width: 7, Margin: 2, HoleDiameter: 2, MillimetersPerSecond: 10},
+		{Notes: []byte{60, 62, 64}, Pitch: 3, Width: 12, Margin: -1, HoleDiameter: 2, MillimetersPerSecond: 10},
+		{Notes: []byte{60, 62, 64}, Pitch: 3, Width: 12, HoleDiameter: 2},
+		{Notes: []byte{60, 62, 64}, Pitch: 3, Width: 12, MillimetersPerSecond: 10},
+		{Notes: []byte{60, 62, 64}, Pitch: 3, Width: 12, HoleDiameter: 4, MillimetersPerSecond: 10},
+	}
+	for i, spec := range invalid {
+		if err := spec.Validate(); err == nil {
+			t.Errorf("spec %d: Validate() = nil; want an error", i)
+		}
+	}
+
+	// An invalid spec is refused before anything is written
+	var f midi.MidiFile
+	if err := midi.CreateImage(f, invalid[0], filepath.Join(t.TempDir(), "image.png")); err == nil {
+		t.Error("CreateImage() = nil with an invalid spec; want an error")
+	}
+}
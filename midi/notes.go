@@ -0,0 +1,196 @@
+package midi
+
+import "sort"
+
+// PairingPolicy type used to choose how NoteOn and NoteOff events with the same
+// channel and key are paired into notes
+type PairingPolicy int
+
+const (
+	// PairFIFO closes the oldest open note on a NoteOff
+	PairFIFO PairingPolicy = iota
+
+	// PairLIFO closes the newest open note on a NoteOff
+	PairLIFO
+
+	// PairRetrigger closes the open note when the key is struck again, so only
+	// one note per channel and key is ever open
+	PairRetrigger
+)
+
+// noteId type used to identify a note by its channel and key
+type noteId struct {
+	channel byte
+	key     byte
+}
+
+// addNote adds a finished note to the track and updates the track's range
+func (t *MidiTrack) addNote(note MidiNote) {
+	t.Notes = append(t.Notes, note)
+
+	// Change the min/max note of the track
+	if note.Key < t.Min {
+		t.Min = note.Key
+	}
+
+	if note.Key > t.Max {
+		t.Max = note.Key
+	}
+}
+
+// pairNotes converts the track's NoteOn and NoteOff events to notes using the
+// given policy. A NoteOff only closes notes with the same channel and key. Notes
+// still open at the end of the track are kept in Unclosed, lasting until the End
+// of Track event
+func (t *MidiTrack) pairNotes(policy PairingPolicy) {
+	notesBeingProcessed := make(map[noteId][]MidiNote)
+	var order []noteId
+	var wallTime int32
+
+	for _, event := range t.Events {
+		wallTime += event.DeltaTick
+		id := noteId{event.Channel, event.Data[0]}
+		open := notesBeingProcessed[id]
+
+		if event.Kind == KindNoteOn {
+			// Close the previous note if the key is struck again
+			if policy == PairRetrigger && len(open) > 0 {
+				t.closeNote(open[0], wallTime)
+				open = open[:0]
+			}
+
+			// Add an 'NoteOn' to the processing notes
+			if _, seen := notesBeingProcessed[id]; !seen {
+				order = append(order, id)
+			}
+			note := MidiNote{Channel: event.Channel, Key: event.Data[0], Velocity: event.Data[1], StartTime: wallTime}
+			notesBeingProcessed[id] = append(open, note)
+		} else if event.Kind == KindNoteOff && len(open) > 0 {
+			// Close a single processing note, picked by the policy
+			if policy == PairLIFO {
+				t.closeNote(open[len(open)-1], wallTime)
+				notesBeingProcessed[id] = open[:len(open)-1]
+			} else {
+				t.closeNote(open[0], wallTime)
+				notesBeingProcessed[id] = open[1:]
+			}
+		}
+	}
+
+	// Report the notes that were never closed
+	if t.EndTick > wallTime {
+		wallTime = t.EndTick
+	}
+	for _, id := range order {
+		for _, note := range notesBeingProcessed[id] {
+			note.Duration = wallTime - note.StartTime
+			t.Unclosed = append(t.Unclosed, note)
+		}
+	}
+	sort.SliceStable(t.Unclosed, func(i, j int) bool {
+		return t.Unclosed[i].StartTime < t.Unclosed[j].StartTime
+	})
+
+	// Keep the notes ordered by their start time
+	sort.SliceStable(t.Notes, func(i, j int) bool {
+		return t.Notes[i].StartTime < t.Notes[j].StartTime
+	})
+}
+
+// closeNote sets the duration of an open note and adds it to the track
+func (t *MidiTrack) closeNote(note MidiNote, wallTime int32) {
+	note.Duration = wallTime - note.StartTime
+	t.addNote(note)
+}
+
+// Channels returns the channels used by the track's events, in ascending order
+func (t *MidiTrack) Channels() []byte {
+	var used [16]bool
+	var channels []byte
+
+	for _, event := range t.Events {
+		used[event.Channel&0x0F] = true
+	}
+	for channel, ok := range used {
+		if ok {
+			channels = append(channels, byte(channel))
+		}
+	}
+
+	return channels
+}
+
+// SplitChannels splits the track into one track per channel. Each new track keeps
+// the name of the original, and the meta and system exclusive events are kept on
+// the first one. Unless the track has an instrument name event, each new track's
+// instrument comes from its channel's program changes. A track using one channel
+// or none is returned unchanged
+func (t *MidiTrack) SplitChannels() []MidiTrack {
+	channels := t.Channels()
+	if len(channels) <= 1 {
+		return []MidiTrack{*t}
+	}
+
+	var tracks []MidiTrack
+	for i, channel := range channels {
+		track := MidiTrack{Name: t.Name, Min: Min_Note, Max: Max_Note, EndTick: t.EndTick}
+		if len(t.MetaOfType(MetaInstrumentName)) > 0 {
+			track.Instrument = t.Instrument
+		}
+		if i == 0 {
+			track.Meta = t.Meta
+			track.SysEx = t.SysEx
+		}
+
+		// Copy the channel's events, moving the skipped events' time into the next delta
+		var wallTime, lastTime int32
+		for _, event := range t.Events {
+			wallTime += event.DeltaTick
+			if event.Channel != channel {
+				continue
+			}
+
+			event.DeltaTick = wallTime - lastTime
+			lastTime = wallTime
+			track.Events = append(track.Events, event)
+		}
+
+		// Copy the channel's notes
+		for _, note := range t.Notes {
+			if note.Channel == channel {
+				track.addNote(note)
+			}
+		}
+		for _, note := range t.Unclosed {
+			if note.Channel == channel {
+				track.Unclosed = append(track.Unclosed, note)
+			}
+		}
+		track.collectPrograms()
+
+		tracks = append(tracks, track)
+	}
+
+	return tracks
+}
+
+// SplitChannels replaces every track using more than one channel with one track
+// per channel. This is mostly useful for format 0 files, which keep every channel
+// in a single track, and which become format 1 files. Format 2 files are left
+// unchanged, since each of their tracks is a separate sequence
+func (f *MidiFile) SplitChannels() {
+	if f.Format == FormatMultiSequence {
+		return
+	}
+
+	var tracks []MidiTrack
+
+	for i := range f.Tracks {
+		tracks = append(tracks, f.Tracks[i].SplitChannels()...)
+	}
+
+	f.Tracks = tracks
+	if len(f.Tracks) > 1 {
+		f.Format = FormatMultiTrack
+	}
+}
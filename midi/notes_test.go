@@ -0,0 +1,136 @@
+package midi_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_NoteChannels(t *testing.T) {
+	// Two channels playing the same key with overlapping notes, plus a program change
+	track := "\x00\xff\x03\x04Solo" +
+		"\x00\xc1\x28" +
+		"\x00\x90\x3c\x50" +
+		"\x0a\x91\x3c\x60" +
+		"\x0a\x80\x3c\x00" +
+		"\x05\x91\x40\x60" +
+		"\x0f\x81\x3c\x00" +
+		"\x00\x91\x40\x00"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(0, 96, track)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []midi.MidiNote{
+		{Channel: 0, Key: 0x3c, Velocity: 0x50, StartTime: 0, Duration: 20},
+		{Channel: 1, Key: 0x3c, Velocity: 0x60, StartTime: 10, Duration: 30},
+		{Channel: 1, Key: 0x40, Velocity: 0x60, StartTime: 25, Duration: 15},
+	}
+	notes := f.Tracks[0].Notes
+	if len(notes) != len(want) {
+		t.Fatalf("parsed %d notes; want %d", len(notes), len(want))
+	}
+	for i := range want {
+		if notes[i] != want[i] {
+			t.Errorf("note %d = %+v; want %+v", i, notes[i], want[i])
+		}
+	}
+	if channels := f.Tracks[0].Channels(); len(channels) != 2 || channels[0] != 0 || channels[1] != 1 {
+		t.Errorf("Channels() = %v; want [0 1]", channels)
+	}
+
+	// Split the single track into one track per channel
+	f.SplitChannels()
+	if len(f.Tracks) != 2 {
+		t.Fatalf("SplitChannels() gave %d tracks; want 2", len(f.Tracks))
+	}
+
+	first, second := f.Tracks[0], f.Tracks[1]
+	if first.Name != "Solo" || second.Name != "Solo" {
+		t.Errorf("split track names = %q, %q; want Solo", first.Name, second.Name)
+	}
+	if len(first.Meta) != 1 || len(second.Meta) != 0 {
+		t.Errorf("split tracks have %d and %d meta events; want 1 and 0", len(first.Meta), len(second.Meta))
+	}
+	if len(first.Notes) != 1 || first.Notes[0] != want[0] {
+		t.Errorf("channel 0 notes = %+v", first.Notes)
+	}
+	if len(second.Notes) != 2 || second.Notes[0] != want[1] || second.Notes[1] != want[2] {
+		t.Errorf("channel 1 notes = %+v", second.Notes)
+	}
+	if second.Min != 0x3c || second.Max != 0x40 {
+		t.Errorf("channel 1 range = %d-%d; want 60-64", second.Min, second.Max)
+	}
+
+	// Delta times are recomputed so each event keeps its absolute time
+	var deltas []int32
+	for _, event := range second.Events {
+		deltas = append(deltas, event.DeltaTick)
+	}
+	if want := []int32{0, 10, 15, 15, 0}; !reflect.DeepEqual(deltas, want) {
+		t.Errorf("channel 1 deltas = %v; want %v", deltas, want)
+	}
+}
+
+func Test_NotePairing(t *testing.T) {
+	// Overlapping notes on one key, then a note that is never released
+	track := "\x00\x90\x3c\x01" +
+		"\x0a\x90\x3c\x02" +
+		"\x0a\x80\x3c\x00" +
+		"\x0a\x80\x3c\x00" +
+		"\x0a\x90\x3c\x03" +
+		"\x0a\x80\x40\x00"
+
+	tests := []struct {
+		name   string
+		policy midi.PairingPolicy
+		notes  []midi.MidiNote
+	}{
+		{"FIFO", midi.PairFIFO, []midi.MidiNote{
+			{Key: 0x3c, Velocity: 1, StartTime: 0, Duration: 20},
+			{Key: 0x3c, Velocity: 2, StartTime: 10, Duration: 20},
+		}},
+		{"LIFO", midi.PairLIFO, []midi.MidiNote{
+			{Key: 0x3c, Velocity: 1, StartTime: 0, Duration: 30},
+			{Key: 0x3c, Velocity: 2, StartTime: 10, Duration: 10},
+		}},
+		{"Retrigger", midi.PairRetrigger, []midi.MidiNote{
+			{Key: 0x3c, Velocity: 1, StartTime: 0, Duration: 10},
+			{Key: 0x3c, Velocity: 2, StartTime: 10, Duration: 10},
+		}},
+	}
+
+	for _, test := range tests {
+		f := midi.MidiFile{Pairing: test.policy}
+		if _, err := f.ParseBytes(smf(0, 96, track)); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(f.Tracks[0].Notes, test.notes) {
+			t.Errorf("%s: notes = %+v; want %+v", test.name, f.Tracks[0].Notes, test.notes)
+		}
+
+		unclosed := []midi.MidiNote{{Key: 0x3c, Velocity: 3, StartTime: 40, Duration: 10}}
+		if !reflect.DeepEqual(f.Tracks[0].Unclosed, unclosed) {
+			t.Errorf("%s: unclosed = %+v; want %+v", test.name, f.Tracks[0].Unclosed, unclosed)
+		}
+	}
+}
+
+func Test_UnclosedEndOfTrack(t *testing.T) {
+	// A note that is never released, with End of Track 480 ticks later
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(0, 96, "\x00\x90\x3c\x40\x83\x60\xff\x2f\x00")); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Tracks[0].EndTick != 480 {
+		t.Errorf("EndTick = %d; want 480", f.Tracks[0].EndTick)
+	}
+	want := []midi.MidiNote{{Key: 0x3c, Velocity: 0x40, StartTime: 0, Duration: 480}}
+	if !reflect.DeepEqual(f.Tracks[0].Unclosed, want) {
+		t.Errorf("unclosed = %+v; want %+v", f.Tracks[0].Unclosed, want)
+	}
+}
@@ -0,0 +1,336 @@
+package midi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PageSize type used to hold the size of a sheet of paper in portrait, in
+// millimeters
+type PageSize struct {
+	Name   string  `json:"name"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Common paper sizes
+var (
+	PageA4     = PageSize{"A4", 210, 297}
+	PageLetter = PageSize{"Letter", 215.9, 279.4}
+)
+
+// PDFOptions type used to configure how a strip is tiled onto pages. The strip is
+// cut into segments as wide as the printable area, stacked down each page. Each
+// segment repeats the last Overlap millimeters of the previous one, so the
+// segments can be glued back together
+type PDFOptions struct {
+	Page      PageSize `json:"page"`
+	Landscape bool     `json:"landscape"`
+	Margin    float64  `json:"margin"`  // Blank space on every side of the page
+	Overlap   float64  `json:"overlap"` // Length of strip shared by neighbouring segments
+}
+
+// DefaultPDFOptions prints on landscape A4 with 10 mm margins and overlaps
+var DefaultPDFOptions = PDFOptions{Page: PageA4, Landscape: true, Margin: 10, Overlap: 10}
+
+// Space kept above and below each segment for its label and alignment marks
+const segmentGap = 5.0
+
+// Length of the alignment marks outside the strip
+const markLength = 3.0
+
+// CreatePDF function creates a PDF of the paper strip for the MidiFile on the
+// given music box, tiled onto pages
+func CreatePDF(file MidiFile, spec MusicBoxSpec, options PDFOptions, outputPath string) error {
+	layout, err := NewLayout(&file, spec)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	if err := layout.WritePDF(f, options); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// pageSize returns the width and height of the page in its orientation
+func (o PDFOptions) pageSize() (float64, float64) {
+	if o.Landscape {
+		return o.Page.Height, o.Page.Width
+	}
+
+	return o.Page.Width, o.Page.Height
+}
+
+// pdfSegment type used to hold where a piece of the strip is drawn
+type pdfSegment struct {
+	start, end float64 // Range of the strip, in millimeters along it
+	x, y       float64 // Top left corner of the segment on the page
+}
+
+// segments cuts the strip into pages of segments
+func (l Layout) segments(o PDFOptions) ([][]pdfSegment, error) {
+	width, height := o.pageSize()
+	printWidth := width - 2*o.Margin
+	printHeight := height - 2*o.Margin
+
+	if o.Margin < 0 || o.Overlap < 0 {
+		return nil, errors.New("midi: PDF margins and overlap cannot be negative")
+	}
+	if printWidth <= o.Overlap {
+		return nil, errors.New("midi: PDF page is too narrow for the overlap")
+	}
+
+	rowHeight := l.Spec.Width + 2*segmentGap
+	rows := int(printHeight / rowHeight)
+	if rows < 1 {
+		return nil, fmt.Errorf("midi: a %v mm strip does not fit on the page", l.Spec.Width)
+	}
+
+	var pages [][]pdfSegment
+	for start := 0.0; ; start += printWidth - o.Overlap {
+		if len(pages) == 0 || len(pages[len(pages)-1]) == rows {
+			pages = append(pages, nil)
+		}
+
+		page := &pages[len(pages)-1]
+		*page = append(*page, pdfSegment{
+			start: start,
+			end:   math.Min(start+printWidth, l.Length),
+			x:     o.Margin,
+			y:     o.Margin + float64(len(*page))*rowHeight + segmentGap,
+		})
+
+		if start+printWidth >= l.Length {
+			break
+		}
+	}
+
+	return pages, nil
+}
+
+// pdfContent type used to build the content stream of a page. Lengths are given
+// in millimeters from the top left corner of the page, and converted to points
+// from the bottom left corner
+type pdfContent struct {
+	bytes.Buffer
+	height float64 // Height of the page in millimeters
+}
+
+// pt formats millimeters as points
+func pt(v float64) string {
+	return strconv.FormatFloat(math.Round(v*72/25.4*100)/100, 'f', -1, 64)
+}
+
+// point formats a position on the page
+func (c *pdfContent) point(x, y float64) string {
+	return pt(x) + " " + pt(c.height-y)
+}
+
+// line strokes a line between two points
+func (c *pdfContent) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&c.Buffer, "%s m %s l S\n", c.point(x1, y1), c.point(x2, y2))
+}
+
+// rect draws a rectangle from its top left corner, painted with the given operator
+func (c *pdfContent) rect(x, y, w, h float64, op string) {
+	fmt.Fprintf(&c.Buffer, "%s %s %s re %s\n", c.point(x, y+h), pt(w), pt(h), op)
+}
+
+// circle draws a filled circle from four Bézier curves
+func (c *pdfContent) circle(x, y, r float64) {
+	k := r * 0.5523
+	fmt.Fprintf(&c.Buffer, "%s m\n", c.point(x+r, y))
+	fmt.Fprintf(&c.Buffer, "%s %s %s c\n", c.point(x+r, y+k), c.point(x+k, y+r), c.point(x, y+r))
+	fmt.Fprintf(&c.Buffer, "%s %s %s c\n", c.point(x-k, y+r), c.point(x-r, y+k), c.point(x-r, y))
+	fmt.Fprintf(&c.Buffer, "%s %s %s c\n", c.point(x-r, y-k), c.point(x-k, y-r), c.point(x, y-r))
+	fmt.Fprintf(&c.Buffer, "%s %s %s c f\n", c.point(x+k, y-r), c.point(x+r, y-k), c.point(x+r, y))
+}
+
+// text writes a line of text with its baseline at the given position
+func (c *pdfContent) text(x, y, size float64, s string) {
+	s = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+	fmt.Fprintf(&c.Buffer, "BT /F1 %s Tf %s Td (%s) Tj ET\n", pt(size), c.point(x, y), s)
+}
+
+// gray sets the stroke and fill color
+func (c *pdfContent) gray(level float64) {
+	fmt.Fprintf(&c.Buffer, "%s G %s g\n", strconv.FormatFloat(level, 'f', -1, 64), strconv.FormatFloat(level, 'f', -1, 64))
+}
+
+// width sets the line width in millimeters
+func (c *pdfContent) width(mm float64) {
+	fmt.Fprintf(&c.Buffer, "%s w\n", pt(mm))
+}
+
+// alignmentMark draws a glue line across the strip with marks outside both edges
+func (c *pdfContent) alignmentMark(x, y, width float64) {
+	c.gray(0)
+	c.width(0.2)
+	c.line(x, y-markLength, x, y)
+	c.line(x, y+width, x, y+width+markLength)
+	c.Buffer.WriteString("[1 1] 0 d\n")
+	c.line(x, y, x, y+width)
+	c.Buffer.WriteString("[] 0 d\n")
+}
+
+// within returns the sorted positions from start to end
+func within(positions []float64, start, end float64) []float64 {
+	first := sort.SearchFloat64s(positions, start)
+	last := sort.Search(len(positions), func(i int) bool { return positions[i] > end })
+
+	return positions[first:last]
+}
+
+// drawSegment draws a piece of the strip
+func (l Layout) drawSegment(c *pdfContent, s pdfSegment, index int, o PDFOptions) {
+	spec := l.Spec
+	length := s.end - s.start
+	first, last := spec.TineOffset(0), spec.TineOffset(len(spec.Notes)-1)
+
+	// The overlap glued under the previous segment is shaded
+	if index > 0 {
+		c.gray(0.9)
+		c.rect(s.x, s.y, math.Min(o.Overlap, length), spec.Width, "f")
+	}
+
+	// Keep everything inside the segment
+	c.Buffer.WriteString("q\n")
+	c.rect(s.x, s.y, length, spec.Width, "W n")
+
+	c.width(0.1)
+	c.gray(0.75)
+	for _, x := range within(l.Beats, s.start, s.end) {
+		c.line(s.x+x-s.start, s.y+first, s.x+x-s.start, s.y+last)
+	}
+	c.width(0.2)
+	c.gray(0.5)
+	for _, x := range within(l.Bars, s.start, s.end) {
+		c.line(s.x+x-s.start, s.y+first, s.x+x-s.start, s.y+last)
+	}
+
+	c.width(0.1)
+	c.gray(0)
+	for i, key := range spec.Notes {
+		y := s.y + spec.TineOffset(i)
+		c.line(s.x, y, s.x+length, y)
+		c.text(s.x+1, y-0.2, spec.Pitch*0.8, midiToName[key])
+	}
+
+	radius := spec.HoleDiameter / 2
+	for _, hole := range l.Holes {
+		if hole.X+radius < s.start || hole.X-radius > s.end {
+			continue
+		}
+
+		x, y := s.x+hole.X-s.start, s.y+hole.Y
+		switch spec.HoleShape {
+		case HoleSquare:
+			c.rect(x-radius, y-radius, spec.HoleDiameter, spec.HoleDiameter, "f")
+		case HoleCross:
+			c.line(x-radius, y-radius, x+radius, y+radius)
+			c.line(x-radius, y+radius, x+radius, y-radius)
+		default:
+			c.circle(x, y, radius)
+		}
+	}
+	c.Buffer.WriteString("Q\n")
+
+	// Outline, label and alignment marks
+	c.width(0.2)
+	c.rect(s.x, s.y, length, spec.Width, "S")
+	c.text(s.x, s.y-1, 3, fmt.Sprint(index+1))
+
+	if index > 0 {
+		c.alignmentMark(s.x+math.Min(o.Overlap, length), s.y, spec.Width)
+	}
+	if s.end < l.Length {
+		c.alignmentMark(s.x+length, s.y, spec.Width)
+	}
+}
+
+// WritePDF draws the strip as a PDF, cut into segments tiled onto pages. Each
+// segment is numbered, shades the overlap glued under the previous segment, and
+// has alignment marks where it meets its neighbours. Each page is numbered
+func (l Layout) WritePDF(w io.Writer, o PDFOptions) error {
+	pages, err := l.segments(o)
+	if err != nil {
+		return err
+	}
+	width, height := o.pageSize()
+
+	// Draw every page
+	var contents [][]byte
+	index := 0
+	for i, page := range pages {
+		c := &pdfContent{height: height}
+
+		for _, s := range page {
+			l.drawSegment(c, s, index, o)
+			index++
+		}
+
+		footer := "Page " + fmt.Sprint(i+1) + " of " + fmt.Sprint(len(pages))
+		if l.Title != "" {
+			footer = l.Title + " - " + footer
+		}
+		c.gray(0)
+		c.text(o.Margin, height-o.Margin/2, 3, footer)
+
+		contents = append(contents, c.Bytes())
+	}
+
+	// Objects 1 and 2 are the catalog and page tree, 3 is the font, and each page
+	// takes a page and a content object
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprint(4+2*i) + " 0 R"
+	}
+
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids ["+strings.Join(kids, " ")+"] /Count "+fmt.Sprint(len(pages))+" >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	)
+	for i, content := range contents {
+		objects = append(objects,
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 "+pt(width)+" "+pt(height)+"] /Resources << /Font << /F1 3 0 R >> >> /Contents "+fmt.Sprint(5+2*i)+" 0 R >>",
+			"<< /Length "+fmt.Sprint(len(content))+" >>\nstream\n"+string(content)+"endstream",
+		)
+	}
+
+	// Write the objects, recording where each starts for the cross reference table
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err = w.Write(b.Bytes())
+	return err
+}
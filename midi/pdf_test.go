@@ -0,0 +1,133 @@
+package midi_test
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+// checkPDF checks the structure of a PDF, returning its number of pages
+func checkPDF(t *testing.T, data []byte) int {
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("PDF header or trailer is missing")
+	}
+
+	// Every cross reference entry must point at its object
+	start := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if start == nil {
+		t.Fatal("startxref is missing")
+	}
+	xref, _ := strconv.Atoi(string(start[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the cross reference table", xref)
+	}
+	for i, entry := range regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1) {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := strconv.Itoa(i+1) + " 0 obj\n"; !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("object %d is not at offset %d", i+1, offset)
+		}
+	}
+
+	// Every stream must have its declared length
+	for _, stream := range regexp.MustCompile(`(?s)/Length (\d+) >>\nstream\n(.*?)endstream`).FindAllSubmatch(data, -1) {
+		if length, _ := strconv.Atoi(string(stream[1])); length != len(stream[2]) {
+			t.Errorf("stream declares %d bytes; has %d", length, len(stream[2]))
+		}
+	}
+
+	return bytes.Count(data, []byte("/Type /Page /Parent"))
+}
+
+func Test_WritePDF(t *testing.T) {
+	var f midi.MidiFile
+	if _, err := f.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+
+	layout, err := midi.NewLayout(&f, midi.MusicBox30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		options midi.PDFOptions
+		width   float64 // Printable width
+		rows    int     // Segments per page
+	}{
+		{midi.DefaultPDFOptions, 277, 2},
+		{midi.PDFOptions{Page: midi.PageLetter, Margin: 15, Overlap: 20}, 185.9, 3},
+		{midi.PDFOptions{Page: midi.PageA4, Margin: 10, Overlap: 0}, 190, 3},
+	}
+
+	for _, test := range tests {
+		var b bytes.Buffer
+		if err := layout.WritePDF(&b, test.options); err != nil {
+			t.Fatal(err)
+		}
+
+		segments := int(math.Ceil((layout.Length - test.options.Overlap) / (test.width - test.options.Overlap)))
+		want := (segments + test.rows - 1) / test.rows
+		if pages := checkPDF(t, b.Bytes()); pages != want {
+			t.Errorf("%+v: %d pages; want %d", test.options, pages, want)
+		}
+
+		// Beat and bar lines are only drawn on the segments they fall in, so each is
+		// drawn once, or twice where segments overlap
+		perSegment := len(midi.MusicBox30.Notes) + 6 + 2*int(test.options.Overlap/midi.MusicBox30.MillimetersPerBeat+2)
+		if lines, max := bytes.Count(b.Bytes(), []byte(" l S\n")), len(layout.Beats)+len(layout.Bars)+segments*perSegment; lines > max {
+			t.Errorf("%+v: %d lines drawn; want at most %d", test.options, lines, max)
+		}
+
+		// Every page is numbered
+		if !bytes.Contains(b.Bytes(), []byte("(Page "+strconv.Itoa(want)+" of "+strconv.Itoa(want)+")")) {
+			t.Errorf("%+v: last page is not numbered", test.options)
+		}
+	}
+}
+
+func Test_WritePDFErrors(t *testing.T) {
+	f := layoutFile(t)
+	layout, err := midi.NewLayout(&f, midi.MusicBox30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []midi.PDFOptions{
+		{Page: midi.PageA4, Margin: 10, Overlap: 200},
+		{Page: midi.PageA4, Margin: 140},
+		{Page: midi.PageSize{Width: 100, Height: 60}, Margin: 5},
+		{Page: midi.PageA4, Margin: -1},
+	}
+	for _, options := range invalid {
+		if err := layout.WritePDF(&bytes.Buffer{}, options); err == nil {
+			t.Errorf("%+v: WritePDF() = nil; want an error", options)
+		}
+	}
+}
+
+func Test_CreatePDF(t *testing.T) {
+	f := layoutFile(t)
+	f.Tracks[0].Name = "Tune (short)"
+
+	path := filepath.Join(t.TempDir(), "strip.pdf")
+	if err := midi.CreatePDF(f, midi.MusicBox15, midi.DefaultPDFOptions, path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages := checkPDF(t, data); pages != 1 {
+		t.Errorf("%d pages; want 1", pages)
+	}
+	if !bytes.Contains(data, []byte(`(Tune \(short\) - Page 1 of 1)`)) {
+		t.Error("title is not escaped in the footer")
+	}
+}
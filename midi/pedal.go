@@ -0,0 +1,91 @@
+package midi
+
+// Controllers of the pedals that hold notes
+const (
+	ControllerSustain   = 64
+	ControllerSostenuto = 66
+)
+
+// pedalHold type used to hold the span of ticks a pedal was held down
+type pedalHold struct {
+	down int32
+	up   int32
+}
+
+// pedalHolds returns the spans each channel held down the pedal with the given
+// controller. A pedal still down at the end of the track is released there
+func (t *MidiTrack) pedalHolds(controller byte) [16][]pedalHold {
+	var holds [16][]pedalHold
+	var isDown [16]bool
+	var wallTime int32
+
+	for _, event := range t.Events {
+		wallTime += event.DeltaTick
+		if event.Kind != KindControlChange || event.Data[0] != controller {
+			continue
+		}
+
+		// Values of 64 and above press the pedal
+		channel := event.Channel & 0x0F
+		down := event.Data[1] >= 64
+		if down && !isDown[channel] {
+			holds[channel] = append(holds[channel], pedalHold{down: wallTime})
+		} else if !down && isDown[channel] {
+			holds[channel][len(holds[channel])-1].up = wallTime
+		}
+		isDown[channel] = down
+	}
+
+	for channel := range holds {
+		if isDown[channel] {
+			holds[channel][len(holds[channel])-1].up = wallTime
+		}
+	}
+
+	return holds
+}
+
+// applyPedals extends the durations of the track's notes while the sustain or
+// sostenuto pedal holds them. A sustained note released while the sustain pedal is
+// down lasts until the pedal is lifted, and a note whose key is down when the
+// sostenuto pedal is pressed lasts until that pedal is lifted. A held note still
+// ends when its key is struck again
+func (t *MidiTrack) applyPedals() {
+	sustain := t.pedalHolds(ControllerSustain)
+	sostenuto := t.pedalHolds(ControllerSostenuto)
+
+	for i := range t.Notes {
+		note := &t.Notes[i]
+		channel := note.Channel & 0x0F
+		released := note.StartTime + note.Duration
+		end := released
+
+		// Extend the note until neither pedal holds it
+		for extended := true; extended; {
+			extended = false
+
+			for _, hold := range sostenuto[channel] {
+				if note.StartTime <= hold.down && hold.down < released && hold.up > end {
+					end = hold.up
+					extended = true
+				}
+			}
+
+			for _, hold := range sustain[channel] {
+				if hold.down <= end && end < hold.up {
+					end = hold.up
+					extended = true
+				}
+			}
+		}
+
+		// Striking the key again ends the held note
+		for _, other := range t.Notes {
+			if other.Channel == note.Channel && other.Key == note.Key && other.StartTime >= released && other.StartTime > note.StartTime && other.StartTime < end {
+				end = other.StartTime
+			}
+		}
+
+		note.Duration = end - note.StartTime
+	}
+}
@@ -0,0 +1,57 @@
+package midi_test
+
+import (
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_Pedals(t *testing.T) {
+	// Channel 0 holds the sustain pedal from 0 to 50 and strikes key 60 twice.
+	// Channel 1 holds the sostenuto pedal from 10 to 60 while key 48 is down
+	track := "\x00\xb0\x40\x7f" +
+		"\x00\x90\x3c\x40" +
+		"\x00\x91\x30\x40" +
+		"\x0a\x80\x3c\x00" +
+		"\x00\xb1\x42\x7f" +
+		"\x05\x91\x32\x40" +
+		"\x05\x90\x3e\x40" +
+		"\x00\x81\x30\x00" +
+		"\x00\x81\x32\x00" +
+		"\x0a\x90\x3c\x40" +
+		"\x0a\x80\x3c\x00" +
+		"\x00\x80\x3e\x00" +
+		"\x0a\xb0\x40\x00" +
+		"\x0a\xb1\x42\x00"
+
+	type span struct {
+		channel, key  byte
+		start, length int32
+	}
+	tests := []struct {
+		name   string
+		pedals bool
+		notes  []span
+	}{
+		{"without pedals", false, []span{{0, 60, 0, 10}, {1, 48, 0, 20}, {1, 50, 15, 5}, {0, 62, 20, 20}, {0, 60, 30, 10}}},
+		{"with pedals", true, []span{{0, 60, 0, 30}, {1, 48, 0, 60}, {1, 50, 15, 5}, {0, 62, 20, 30}, {0, 60, 30, 20}}},
+	}
+
+	for _, test := range tests {
+		f := midi.MidiFile{Pedals: test.pedals}
+		if _, err := f.ParseBytes(smf(0, 96, track)); err != nil {
+			t.Fatal(err)
+		}
+
+		notes := f.Tracks[0].Notes
+		if len(notes) != len(test.notes) {
+			t.Fatalf("%s: %d notes; want %d", test.name, len(notes), len(test.notes))
+		}
+		for i, want := range test.notes {
+			got := span{notes[i].Channel, notes[i].Key, notes[i].StartTime, notes[i].Duration}
+			if got != want {
+				t.Errorf("%s: note %d = %+v; want %+v", test.name, i, got, want)
+			}
+		}
+	}
+}
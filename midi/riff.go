@@ -0,0 +1,46 @@
+package midi
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// unwrapRIFF moves the reader to the start of the Standard MIDI File held in a
+// RIFF RMID file. Other files are left untouched
+func (f *MidiFile) unwrapRIFF() error {
+	b, err := f.reader.Peek(4)
+	if err != nil || string(b) != "RIFF" {
+		return nil
+	}
+
+	// Read the RIFF header
+	b, err = f.readBytes(12)
+	if err != nil {
+		return err
+	}
+	if string(b[8:]) != "RMID" {
+		f.offset -= 4
+		return f.newError(ErrBadHeader, "RIFF form "+fmt.Sprintf("%q", b[8:])+" is not 'RMID'")
+	}
+
+	// Skip chunks until the data chunk holding the MIDI file
+	for {
+		b, err = f.readBytes(8)
+		if err != nil {
+			return err
+		}
+
+		id := string(b[:4])
+		size := int64(binary.LittleEndian.Uint32(b[4:]))
+		if id == "data" {
+			f.logf("Unwrapped RIFF data chunk of length %d", size)
+			return nil
+		}
+
+		// Chunks are padded to an even length
+		f.logf("Skipping RIFF %s chunk of length %d", id, size)
+		if err := f.skipBytes(size + size%2); err != nil {
+			return err
+		}
+	}
+}
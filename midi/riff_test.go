@@ -0,0 +1,49 @@
+package midi_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+// riffChunk returns a RIFF chunk, padded to an even length
+func riffChunk(id string, data []byte) []byte {
+	b := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(data)))
+	b = append(b, data...)
+	if len(data)%2 == 1 {
+		b = append(b, 0)
+	}
+
+	return b
+}
+
+func Test_ParseRMID(t *testing.T) {
+	data, err := ioutil.ReadFile("./testing/midi.mid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An info list before the MIDI data, as written by common tools
+	body := append([]byte("RMID"), riffChunk("LIST", []byte("INFOINAM\x05\x00\x00\x00Song\x00"))...)
+	body = append(body, riffChunk("data", data)...)
+	rmid := riffChunk("RIFF", body)
+
+	var plain, wrapped midi.MidiFile
+	if _, err := plain.ParseBytes(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wrapped.ParseBytes(rmid); err != nil {
+		t.Fatal(err)
+	}
+	compareFiles(t, "RMID", &wrapped, &plain)
+
+	// Other RIFF forms are refused
+	wave := riffChunk("RIFF", append([]byte("WAVE"), riffChunk("data", data)...))
+	if _, err := wrapped.ParseBytes(wave); !errors.Is(err, midi.ErrBadHeader) {
+		t.Errorf("Parse() of a WAVE file error = %v; want %v", err, midi.ErrBadHeader)
+	}
+}
@@ -0,0 +1,45 @@
+package midi
+
+// TrackTempoMap returns the tempo map timing the given track. The tracks of a
+// format 2 file are separate sequences, so every track after the first uses its
+// own tempo changes. Every other track uses the file's tempo map
+func (f *MidiFile) TrackTempoMap(track int) TempoMap {
+	if f.Format != FormatMultiSequence || track == 0 {
+		return f.TempoMap
+	}
+
+	return newTempoMap(f.TimeDivision, f.Tracks[track].MetaOfType(MetaSetTempo))
+}
+
+// Sequences returns the songs held by the file. Each track of a format 2 file is
+// returned as a format 0 file with its own tempo map. Any other file holds a
+// single song and is returned as it is
+func (f *MidiFile) Sequences() []MidiFile {
+	if f.Format != FormatMultiSequence {
+		return []MidiFile{*f}
+	}
+
+	var sequences []MidiFile
+	for i := range f.Tracks {
+		sequence := MidiFile{
+			Format:       FormatSingleTrack,
+			Tracks:       []MidiTrack{f.Tracks[i]},
+			TimeDivision: f.TimeDivision,
+			TempoMap:     f.TrackTempoMap(i),
+			Logger:       f.Logger,
+			Pairing:      f.Pairing,
+			Lenient:      f.Lenient,
+			Pedals:       f.Pedals,
+
+			RunningStatus: f.RunningStatus,
+		}
+
+		if tempo := f.Tracks[i].MetaOfType(MetaSetTempo); len(tempo) > 0 {
+			sequence.Tempo = tempo[0].Tempo
+		}
+
+		sequences = append(sequences, sequence)
+	}
+
+	return sequences
+}
@@ -0,0 +1,118 @@
+package midi_test
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_FormatMultiSequence(t *testing.T) {
+	// Two songs: one at 120 BPM and one at 60 BPM, each with a note at tick 96
+	first := "\x00\xff\x51\x03\x07\xa1\x20\x60\x90\x3c\x40\x60\x80\x3c\x00"
+	second := "\x00\xff\x51\x03\x0f\x42\x40\x60\x90\x3e\x40\x60\x80\x3e\x00"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(2, 96, first, second)); err != nil {
+		t.Fatal(err)
+	}
+	if f.Format != midi.FormatMultiSequence {
+		t.Errorf("Format = %d; want 2", f.Format)
+	}
+
+	// The file's tempo map is the first sequence's
+	if want := []midi.TempoChange{{Tick: 0, Tempo: 500000}}; !reflect.DeepEqual(f.TempoMap.Changes, want) {
+		t.Errorf("TempoMap.Changes = %v; want %v", f.TempoMap.Changes, want)
+	}
+	if tempoMap := f.TrackTempoMap(1); tempoMap.TempoAt(0) != 1000000 {
+		t.Errorf("TrackTempoMap(1).TempoAt(0) = %d; want 1000000", tempoMap.TempoAt(0))
+	}
+
+	// Each note is timed by its own sequence's tempo
+	timeline := f.Timeline()
+	if len(timeline) != 2 || math.Abs(timeline[0].Start-0.5) > 1e-9 || math.Abs(timeline[1].Start-1) > 1e-9 {
+		t.Errorf("Timeline() = %+v; want notes at 0.5 and 1 seconds", timeline)
+	}
+
+	sequences := f.Sequences()
+	if len(sequences) != 2 {
+		t.Fatalf("Sequences() returned %d files; want 2", len(sequences))
+	}
+	for i, tempo := range []int32{500000, 1000000} {
+		s := sequences[i]
+		if s.Format != midi.FormatSingleTrack || len(s.Tracks) != 1 || s.Tempo != tempo || s.TempoMap.TempoAt(0) != tempo {
+			t.Errorf("sequence %d = format %d, %d tracks, tempo %d; want format 0, 1 track, tempo %d", i, s.Format, len(s.Tracks), s.Tempo, tempo)
+		}
+	}
+
+	// The format and each sequence's tempo survive a round trip
+	_, parsed := roundTrip(t, &f)
+	compareFiles(t, "format 2", &parsed, &f)
+	if parsed.Format != midi.FormatMultiSequence {
+		t.Errorf("written Format = %d; want 2", parsed.Format)
+	}
+
+	// Splitting channels leaves the sequences alone
+	f.SplitChannels()
+	if len(f.Tracks) != 2 {
+		t.Errorf("SplitChannels() made %d tracks; want 2", len(f.Tracks))
+	}
+
+	// The sequences cannot share one strip
+	if err := midi.CreateImage(f, midi.MusicBox15, filepath.Join(t.TempDir(), "song.png")); err == nil {
+		t.Error("CreateImage() = nil for a format 2 file; want an error")
+	}
+
+	// Every sequence gets its own image
+	dir := t.TempDir()
+	paths, err := midi.CreateSequenceImages(f, midi.MusicBox15, filepath.Join(dir, "song.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "song-1.png"), filepath.Join(dir, "song-2.png")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("CreateSequenceImages() = %v; want %v", paths, want)
+	}
+	for _, path := range want {
+		if _, err := os.Stat(path); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func Test_FormatSingleTrack(t *testing.T) {
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(0, 96, "\x00\x90\x3c\x40\x00\x91\x3e\x40\x60\x80\x3c\x00\x00\x81\x3e\x00")); err != nil {
+		t.Fatal(err)
+	}
+	if f.Format != midi.FormatSingleTrack || len(f.Sequences()) != 1 {
+		t.Errorf("Format = %d with %d sequences; want 0 with 1", f.Format, len(f.Sequences()))
+	}
+
+	// Splitting the channels makes a format 1 file
+	f.SplitChannels()
+	if f.Format != midi.FormatMultiTrack || len(f.Tracks) != 2 {
+		t.Errorf("after SplitChannels() Format = %d with %d tracks; want 1 with 2", f.Format, len(f.Tracks))
+	}
+}
+
+func Test_FormatUnknown(t *testing.T) {
+	data := smf(3, 96, "\x00\x90\x3c\x40\x60\x80\x3c\x00")
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(data); !errors.Is(err, midi.ErrBadHeader) {
+		t.Errorf("ParseBytes() = %v; want %v", err, midi.ErrBadHeader)
+	}
+
+	f = midi.MidiFile{Lenient: true}
+	if _, err := f.ParseBytes(data); err != nil {
+		t.Fatal(err)
+	}
+	if f.Format != midi.FormatMultiTrack || len(f.Warnings) != 1 || !errors.Is(&f.Warnings[0], midi.ErrBadHeader) {
+		t.Errorf("lenient Format = %d with warnings %v; want 1 with one %v", f.Format, f.Warnings, midi.ErrBadHeader)
+	}
+}
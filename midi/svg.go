@@ -0,0 +1,109 @@
+package midi
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// CreateSVG function creates an SVG image of the paper strip for the MidiFile on
+// the given music box. The image is drawn in millimeters, so it prints at the
+// exact size of the strip
+func CreateSVG(file MidiFile, spec MusicBoxSpec, outputPath string) error {
+	layout, err := NewLayout(&file, spec)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	if err := layout.WriteSVG(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// mm formats a length in millimeters for an SVG attribute
+func mm(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// WriteSVG draws the strip as an SVG image in millimeters, with the tine lines,
+// beat and bar lines, holes, the name of each tine's note and the title
+func (l Layout) WriteSVG(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	spec := l.Spec
+	first, last := spec.TineOffset(0), spec.TineOffset(len(spec.Notes)-1)
+
+	fmt.Fprintf(out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%smm\" height=\"%smm\" viewBox=\"0 0 %s %s\">\n",
+		mm(l.Length), mm(spec.Width), mm(l.Length), mm(spec.Width))
+	if l.Title != "" {
+		fmt.Fprintf(out, "<title>%s</title>\n", html.EscapeString(l.Title))
+	}
+
+	// Outline of the strip
+	fmt.Fprintf(out, "<rect x=\"0\" y=\"0\" width=\"%s\" height=\"%s\" fill=\"white\" stroke=\"black\" stroke-width=\"0.2\"/>\n",
+		mm(l.Length), mm(spec.Width))
+
+	// Beat and bar lines across the tines
+	out.WriteString("<g stroke=\"#c0c0c0\" stroke-width=\"0.1\">\n")
+	for _, x := range l.Beats {
+		fmt.Fprintf(out, "<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\"/>\n", mm(x), mm(first), mm(x), mm(last))
+	}
+	out.WriteString("</g>\n<g stroke=\"#808080\" stroke-width=\"0.2\">\n")
+	for _, x := range l.Bars {
+		fmt.Fprintf(out, "<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\"/>\n", mm(x), mm(first), mm(x), mm(last))
+	}
+	out.WriteString("</g>\n")
+
+	// Tine lines and their note names
+	out.WriteString("<g stroke=\"black\" stroke-width=\"0.1\">\n")
+	for i := range spec.Notes {
+		y := spec.TineOffset(i)
+		fmt.Fprintf(out, "<line x1=\"0\" y1=\"%s\" x2=\"%s\" y2=\"%s\"/>\n", mm(y), mm(l.Length), mm(y))
+	}
+	out.WriteString("</g>\n")
+
+	fmt.Fprintf(out, "<g font-family=\"sans-serif\" font-size=\"%s\" dominant-baseline=\"middle\">\n", mm(spec.Pitch*0.8))
+	for i, key := range spec.Notes {
+		fmt.Fprintf(out, "<text x=\"1\" y=\"%s\">%s</text>\n", mm(spec.TineOffset(i)), html.EscapeString(midiToName[key]))
+	}
+	out.WriteString("</g>\n")
+
+	// Holes
+	radius := spec.HoleDiameter / 2
+	out.WriteString("<g fill=\"black\" stroke=\"black\" stroke-width=\"0.1\">\n")
+	for _, hole := range l.Holes {
+		switch spec.HoleShape {
+		case HoleSquare:
+			fmt.Fprintf(out, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\"/>\n",
+				mm(hole.X-radius), mm(hole.Y-radius), mm(spec.HoleDiameter), mm(spec.HoleDiameter))
+		case HoleCross:
+			fmt.Fprintf(out, "<path d=\"M%s %sL%s %sM%s %sL%s %s\"/>\n",
+				mm(hole.X-radius), mm(hole.Y-radius), mm(hole.X+radius), mm(hole.Y+radius),
+				mm(hole.X-radius), mm(hole.Y+radius), mm(hole.X+radius), mm(hole.Y-radius))
+		default:
+			fmt.Fprintf(out, "<circle cx=\"%s\" cy=\"%s\" r=\"%s\"/>\n", mm(hole.X), mm(hole.Y), mm(radius))
+		}
+	}
+	out.WriteString("</g>\n")
+
+	// Title in the margin above the first tine
+	if l.Title != "" && first >= spec.Pitch {
+		fmt.Fprintf(out, "<text x=\"%s\" y=\"%s\" font-family=\"sans-serif\" font-size=\"%s\" dominant-baseline=\"middle\">%s</text>\n",
+			mm(spec.LeadIn), mm(first/2), mm(math.Min(first*0.6, 5)), html.EscapeString(l.Title))
+	}
+
+	out.WriteString("</svg>\n")
+
+	return out.Flush()
+}
@@ -0,0 +1,98 @@
+package midi_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_WriteSVG(t *testing.T) {
+	f := layoutFile(t)
+	f.Tracks[0].Name = "Song & Dance"
+	spec := midi.MusicBoxSpec{Notes: []byte{60, 62, 64}, Pitch: 3, Width: 12, Margin: 4, LeadIn: 10, LeadOut: 5, HoleDiameter: 2, MillimetersPerBeat: 8}
+
+	layout, err := midi.NewLayout(&f, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout.Title != "Song & Dance" {
+		t.Errorf("Title = %q; want %q", layout.Title, "Song & Dance")
+	}
+
+	var b bytes.Buffer
+	if err := layout.WriteSVG(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	// Count the elements of the image, which must be well formed
+	counts := make(map[string]int)
+	var root xml.StartElement
+	var text []string
+	decoder := xml.NewDecoder(&b)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v", err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			if token.Name.Local == "svg" {
+				root = token
+			}
+			counts[token.Name.Local]++
+		case xml.CharData:
+			if s := strings.TrimSpace(string(token)); s != "" {
+				text = append(text, s)
+			}
+		}
+	}
+
+	// The strip is drawn at its size in millimeters
+	attrs := make(map[string]string)
+	for _, attr := range root.Attr {
+		attrs[attr.Name.Local] = attr.Value
+	}
+	if attrs["width"] != "31mm" || attrs["height"] != "12mm" || attrs["viewBox"] != "0 0 31 12" {
+		t.Errorf("svg size = %s x %s, viewBox %q; want 31mm x 12mm, viewBox \"0 0 31 12\"", attrs["width"], attrs["height"], attrs["viewBox"])
+	}
+
+	// Two beat lines, one bar line and three tine lines
+	if counts["circle"] != 2 || counts["line"] != 6 {
+		t.Errorf("%d circles and %d lines; want 2 and 6", counts["circle"], counts["line"])
+	}
+
+	want := "Song & Dance,C4,D4,E4,Song & Dance"
+	if got := strings.Join(text, ","); got != want {
+		t.Errorf("text = %q; want %q", got, want)
+	}
+}
+
+func Test_CreateSVG(t *testing.T) {
+	var f midi.MidiFile
+	if _, err := f.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "strip.svg")
+	if err := midi.CreateSVG(f, midi.MusicBox30, path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("<svg ")) || !bytes.Contains(data, []byte("<circle ")) {
+		t.Error("CreateSVG() did not draw any holes")
+	}
+}
@@ -0,0 +1,49 @@
+package midi
+
+// SysExEvent type used to hold a system exclusive message or escape packet from a
+// track with its absolute tick
+type SysExEvent struct {
+	Tick int32 `json:"tick"`
+
+	// Escape is true for an 0xF7 packet, which either continues a system exclusive
+	// message split across packets or holds bytes to send as they are
+	Escape bool `json:"escape"`
+
+	// Data holds the bytes after the length. A complete system exclusive message
+	// ends with 0xF7
+	Data []byte `json:"data"`
+}
+
+// Status returns the status byte the event is written with
+func (e SysExEvent) Status() byte {
+	if e.Escape {
+		return 0xF7
+	}
+
+	return 0xF0
+}
+
+// parseSysEx reads a system exclusive message or escape packet and adds it to the
+// current track
+func (f *MidiFile) parseSysEx(trackIndex int, status byte, tick int32) error {
+	length, err := f.readValue()
+	if err != nil {
+		return err
+	}
+
+	data, err := f.readBytes(int(length))
+	if err != nil {
+		return err
+	}
+
+	event := SysExEvent{Tick: tick, Escape: status == 0xF7, Data: data}
+	f.Tracks[trackIndex].SysEx = append(f.Tracks[trackIndex].SysEx, event)
+
+	if event.Escape {
+		f.logf("System exclusive escape: % X", data)
+	} else {
+		f.logf("System exclusive: % X", data)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package midi_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_ParseSysEx(t *testing.T) {
+	// A GM reset, a message split across an 0xF0 packet and an 0xF7 continuation,
+	// and an escape holding a real time message. Running status must not carry
+	// over any of them
+	track := "\x00\xf0\x05\x7e\x7f\x09\x01\xf7" +
+		"\x00\x90\x3c\x40" +
+		"\x10\xf0\x03\x43\x12\x00" +
+		"\x08\xf7\x02\x07\xf7" +
+		"\x00\xf7\x01\xfa" +
+		"\x00\x90\x3c\x00" +
+		"\x00\xff\x60\x03abc" + // Unknown meta type, skipped by its length
+		"\x08\x90\x3e\x40\x10\x3e\x00"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(0, 96, track)); err != nil {
+		t.Fatalf("ParseBytes() = %v", err)
+	}
+
+	want := []midi.SysExEvent{
+		{Tick: 0, Data: []byte{0x7e, 0x7f, 0x09, 0x01, 0xf7}},
+		{Tick: 16, Data: []byte{0x43, 0x12, 0x00}},
+		{Tick: 24, Escape: true, Data: []byte{0x07, 0xf7}},
+		{Tick: 24, Escape: true, Data: []byte{0xfa}},
+	}
+	if got := f.Tracks[0].SysEx; !reflect.DeepEqual(got, want) {
+		t.Errorf("SysEx = %+v; want %+v", got, want)
+	}
+
+	wantNotes := []midi.MidiNote{
+		{Channel: 0, Key: 0x3c, Velocity: 0x40, StartTime: 0, Duration: 24},
+		{Channel: 0, Key: 0x3e, Velocity: 0x40, StartTime: 32, Duration: 16},
+	}
+	if got := f.Tracks[0].Notes; !reflect.DeepEqual(got, wantNotes) {
+		t.Errorf("Notes = %+v; want %+v", got, wantNotes)
+	}
+
+	if meta := f.Tracks[0].MetaOfType(0x60); len(meta) != 1 || string(meta[0].Data) != "abc" {
+		t.Errorf("MetaOfType(0x60) = %+v; want one event holding \"abc\"", meta)
+	}
+
+	// The events survive being written back
+	_, parsed := roundTrip(t, &f)
+	compareFiles(t, "sysex", &parsed, &f)
+}
+
+func Test_ParseSystemMessages(t *testing.T) {
+	// A real time message is not allowed in a file
+	data := smf(0, 96, "\x00\xf8\x00\x90\x3c\x40\x10\x80\x3c\x00")
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(data); !errors.Is(err, midi.ErrUnknownStatus) {
+		t.Errorf("ParseBytes() = %v; want %v", err, midi.ErrUnknownStatus)
+	}
+
+	// Lenient mode skips it
+	f = midi.MidiFile{Lenient: true}
+	if _, err := f.ParseBytes(data); err != nil {
+		t.Fatalf("lenient ParseBytes() = %v", err)
+	}
+	if len(f.Warnings) != 1 || !errors.Is(&f.Warnings[0], midi.ErrUnknownStatus) {
+		t.Errorf("Warnings = %v; want one %v", f.Warnings, midi.ErrUnknownStatus)
+	}
+	if len(f.Tracks[0].Notes) != 1 {
+		t.Errorf("%d notes; want 1", len(f.Tracks[0].Notes))
+	}
+}
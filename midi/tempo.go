@@ -0,0 +1,190 @@
+package midi
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultTempo is the tempo of a MIDI file before its first tempo change, in
+// microseconds per quarter note (120 BPM)
+const DefaultTempo = 500000
+
+// DefaultTicksPerQuarter is the time division lenient parsing uses in place of an
+// invalid one
+const DefaultTicksPerQuarter = 480
+
+// TimeDivision type used to hold the time division of a MIDI file. Metrical files
+// count ticks per quarter note, while SMPTE files count ticks per frame
+type TimeDivision struct {
+	TicksPerQuarter int `json:"ticksPerQuarter,omitempty"`
+	FramesPerSecond int `json:"framesPerSecond,omitempty"` // 24, 25, 29 (29.97 drop frame) or 30
+	TicksPerFrame   int `json:"ticksPerFrame,omitempty"`
+}
+
+// decodeTimeDivision decodes the two time division bytes of a file header
+func decodeTimeDivision(b []byte) TimeDivision {
+	// The high bit selects the SMPTE form, where the high byte is the negative frame rate
+	if b[0]&0x80 != 0 {
+		return TimeDivision{FramesPerSecond: int(-int8(b[0])), TicksPerFrame: int(b[1])}
+	}
+
+	return TimeDivision{TicksPerQuarter: int(b[0])<<8 | int(b[1])}
+}
+
+// encode encodes the division as the two time division bytes of a file header
+func (d TimeDivision) encode() [2]byte {
+	if d.IsSMPTE() {
+		return [2]byte{byte(-int8(d.FramesPerSecond)), byte(d.TicksPerFrame)}
+	}
+
+	return [2]byte{byte(d.TicksPerQuarter >> 8), byte(d.TicksPerQuarter)}
+}
+
+// valid returns true if the division has a SMPTE frame rate of 24, 25, 29 or 30,
+// and more than zero ticks per frame or quarter note
+func (d TimeDivision) valid() bool {
+	if d.IsSMPTE() {
+		switch d.FramesPerSecond {
+		case 24, 25, 29, 30:
+			return d.TicksPerFrame > 0
+		}
+		return false
+	}
+
+	return d.TicksPerQuarter > 0
+}
+
+// IsSMPTE returns true if the division counts ticks per SMPTE frame
+func (d TimeDivision) IsSMPTE() bool {
+	return d.FramesPerSecond != 0
+}
+
+// SecondsPerTick returns how long a tick lasts at the given tempo. The tempo is
+// ignored for SMPTE divisions
+func (d TimeDivision) SecondsPerTick(tempo int32) float64 {
+	if d.IsSMPTE() {
+		if d.TicksPerFrame <= 0 {
+			return 0
+		}
+
+		// 29 frames per second stands for the 29.97 drop frame rate
+		fps := float64(d.FramesPerSecond)
+		if d.FramesPerSecond == 29 {
+			fps = 30000.0 / 1001.0
+		}
+
+		return 1.0 / (fps * float64(d.TicksPerFrame))
+	}
+
+	if d.TicksPerQuarter <= 0 {
+		return 0
+	}
+
+	return float64(tempo) / 1000000.0 / float64(d.TicksPerQuarter)
+}
+
+// String returns a description of the division
+func (d TimeDivision) String() string {
+	if d.IsSMPTE() {
+		return fmt.Sprint(d.FramesPerSecond) + " fps, " + fmt.Sprint(d.TicksPerFrame) + " ticks per frame"
+	}
+
+	return fmt.Sprint(d.TicksPerQuarter) + " ticks per quarter note"
+}
+
+// TempoChange type used to hold a single tempo change
+type TempoChange struct {
+	Tick  int32 `json:"tick"`
+	Tempo int32 `json:"tempo"` // Microseconds per quarter note
+}
+
+// TempoMap type used to hold every tempo change of a file and convert between
+// ticks and seconds
+type TempoMap struct {
+	Changes      []TempoChange `json:"changes"`
+	TimeDivision TimeDivision  `json:"timeDivision"`
+}
+
+// newTempoMap creates a TempoMap from a list of tempo meta events ordered by tick
+func newTempoMap(timeDivision TimeDivision, events []MetaEvent) TempoMap {
+	m := TempoMap{TimeDivision: timeDivision}
+
+	for _, event := range events {
+		if event.Tempo <= 0 {
+			continue
+		}
+
+		// A later change at the same tick replaces the earlier one
+		if n := len(m.Changes); n > 0 && m.Changes[n-1].Tick == event.Tick {
+			m.Changes[n-1].Tempo = event.Tempo
+			continue
+		}
+		m.Changes = append(m.Changes, TempoChange{event.Tick, event.Tempo})
+	}
+
+	return m
+}
+
+// secondsPerTick returns how long a tick lasts at the given tempo
+func (m *TempoMap) secondsPerTick(tempo int32) float64 {
+	return m.TimeDivision.SecondsPerTick(tempo)
+}
+
+// TempoAt returns the tempo in effect at the given tick
+func (m *TempoMap) TempoAt(tick int32) int32 {
+	tempo := int32(DefaultTempo)
+
+	for _, change := range m.Changes {
+		if change.Tick > tick {
+			break
+		}
+		tempo = change.Tempo
+	}
+
+	return tempo
+}
+
+// TickToSeconds converts an absolute tick to seconds from the start of the file
+func (m *TempoMap) TickToSeconds(tick int32) float64 {
+	var seconds float64
+	var lastTick int32
+	tempo := int32(DefaultTempo)
+
+	// Add up the length of every tempo section before the tick
+	for _, change := range m.Changes {
+		if change.Tick >= tick {
+			break
+		}
+		seconds += float64(change.Tick-lastTick) * m.secondsPerTick(tempo)
+		lastTick = change.Tick
+		tempo = change.Tempo
+	}
+
+	return seconds + float64(tick-lastTick)*m.secondsPerTick(tempo)
+}
+
+// SecondsToTick converts seconds from the start of the file to the nearest
+// absolute tick
+func (m *TempoMap) SecondsToTick(seconds float64) int32 {
+	var start float64
+	var lastTick int32
+	tempo := int32(DefaultTempo)
+
+	// Find the tempo section containing the time
+	for _, change := range m.Changes {
+		end := start + float64(change.Tick-lastTick)*m.secondsPerTick(tempo)
+		if end > seconds {
+			break
+		}
+		start = end
+		lastTick = change.Tick
+		tempo = change.Tempo
+	}
+
+	perTick := m.secondsPerTick(tempo)
+	if perTick == 0 {
+		return lastTick
+	}
+
+	return lastTick + int32(math.Round((seconds-start)/perTick))
+}
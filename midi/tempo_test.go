@@ -0,0 +1,125 @@
+package midi_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_TempoMap(t *testing.T) {
+	// 120 BPM, then 240 BPM from tick 96 and 60 BPM from tick 192
+	track := "\x00\xff\x51\x03\x07\xa1\x20" +
+		"\x60\xff\x51\x03\x03\xd0\x90" +
+		"\x60\xff\x51\x03\x0f\x42\x40"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(1, 96, track)); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Tempo != 500000 {
+		t.Errorf("Tempo = %d; want 500000", f.Tempo)
+	}
+	if len(f.TempoMap.Changes) != 3 {
+		t.Fatalf("tempo map has %d changes; want 3", len(f.TempoMap.Changes))
+	}
+
+	tests := []struct {
+		tick    int32
+		seconds float64
+		tempo   int32
+	}{
+		{0, 0, 500000},
+		{48, 0.25, 500000},
+		{96, 0.5, 250000},
+		{144, 0.625, 250000},
+		{192, 0.75, 1000000},
+		{288, 1.75, 1000000},
+	}
+
+	for _, test := range tests {
+		if s := f.TempoMap.TickToSeconds(test.tick); math.Abs(s-test.seconds) > 1e-9 {
+			t.Errorf("TickToSeconds(%d) = %v; want %v", test.tick, s, test.seconds)
+		}
+		if tick := f.TempoMap.SecondsToTick(test.seconds); tick != test.tick {
+			t.Errorf("SecondsToTick(%v) = %d; want %d", test.seconds, tick, test.tick)
+		}
+		if tempo := f.TempoMap.TempoAt(test.tick); tempo != test.tempo {
+			t.Errorf("TempoAt(%d) = %d; want %d", test.tick, tempo, test.tempo)
+		}
+	}
+
+	// Every tick survives a round trip through seconds
+	for tick := int32(0); tick < 1000; tick++ {
+		if got := f.TempoMap.SecondsToTick(f.TempoMap.TickToSeconds(tick)); got != tick {
+			t.Fatalf("round trip of tick %d gave %d", tick, got)
+		}
+	}
+}
+
+func Test_TempoMapDefault(t *testing.T) {
+	m := midi.TempoMap{TimeDivision: midi.TimeDivision{TicksPerQuarter: 480}}
+
+	if s := m.TickToSeconds(960); s != 1.0 {
+		t.Errorf("TickToSeconds(960) = %v; want 1 at the default tempo", s)
+	}
+	if tick := m.SecondsToTick(1.5); tick != 1440 {
+		t.Errorf("SecondsToTick(1.5) = %d; want 1440", tick)
+	}
+}
+
+func Test_TimeDivision(t *testing.T) {
+	var f midi.MidiFile
+	if _, err := f.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+	if want := (midi.TimeDivision{TicksPerQuarter: 480}); f.TimeDivision != want || f.TimeDivision.IsSMPTE() {
+		t.Errorf("TimeDivision = %v; want %v", f.TimeDivision, want)
+	}
+
+	// 25 fps with 40 ticks per frame is one millisecond per tick, whatever the tempo
+	track := "\x00\xff\x51\x03\x0f\x42\x40\x60\xff\x51\x03\x03\xd0\x90"
+	if _, err := f.ParseBytes(smf(1, 0xE728, track)); err != nil {
+		t.Fatal(err)
+	}
+	if want := (midi.TimeDivision{FramesPerSecond: 25, TicksPerFrame: 40}); f.TimeDivision != want || !f.TimeDivision.IsSMPTE() {
+		t.Errorf("TimeDivision = %v; want %v", f.TimeDivision, want)
+	}
+	if s := f.TempoMap.TickToSeconds(1000); math.Abs(s-1.0) > 1e-9 {
+		t.Errorf("TickToSeconds(1000) = %v; want 1", s)
+	}
+	if tick := f.TempoMap.SecondsToTick(2.5); tick != 2500 {
+		t.Errorf("SecondsToTick(2.5) = %d; want 2500", tick)
+	}
+
+	// 29 stands for 29.97 drop frame
+	drop := midi.TimeDivision{FramesPerSecond: 29, TicksPerFrame: 100}
+	if s := drop.SecondsPerTick(midi.DefaultTempo); math.Abs(s-1001.0/3000000.0) > 1e-12 {
+		t.Errorf("SecondsPerTick() = %v for 29.97 fps", s)
+	}
+}
+
+func Test_TimeDivisionInvalid(t *testing.T) {
+	for _, division := range []int{0, 0x8000, 0xE700, 0xEC28} {
+		data := smf(1, division, "\x00\x90\x3c\x40\x60\x80\x3c\x00")
+
+		var f midi.MidiFile
+		if _, err := f.ParseBytes(data); !errors.Is(err, midi.ErrBadHeader) {
+			t.Errorf("division %#04x: ParseBytes() = %v; want %v", division, err, midi.ErrBadHeader)
+		}
+
+		// Lenient mode falls back to the default division
+		f = midi.MidiFile{Lenient: true}
+		if _, err := f.ParseBytes(data); err != nil {
+			t.Fatalf("division %#04x: lenient ParseBytes() = %v", division, err)
+		}
+		if len(f.Warnings) != 1 || !errors.Is(&f.Warnings[0], midi.ErrBadHeader) {
+			t.Errorf("division %#04x: Warnings = %v; want one %v", division, f.Warnings, midi.ErrBadHeader)
+		}
+		if want := (midi.TimeDivision{TicksPerQuarter: midi.DefaultTicksPerQuarter}); f.TimeDivision != want {
+			t.Errorf("division %#04x: TimeDivision = %v; want %v", division, f.TimeDivision, want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package midi
+
+import "sort"
+
+// TimedNote type used to hold a note placed on the timeline of the whole file,
+// with its start and end in both ticks and seconds
+type TimedNote struct {
+	Track     int     `json:"track"`
+	Channel   byte    `json:"channel"`
+	Key       byte    `json:"key"`
+	Velocity  byte    `json:"velocity"`
+	StartTick int32   `json:"startTick"`
+	EndTick   int32   `json:"endTick"`
+	Start     float64 `json:"start"` // Seconds from the start of the file
+	End       float64 `json:"end"`
+}
+
+// Duration returns the length of the note in seconds
+func (n TimedNote) Duration() float64 {
+	return n.End - n.Start
+}
+
+// IsPercussion returns true if the note is on the percussion channel
+func (n TimedNote) IsPercussion() bool {
+	return n.Channel == PercussionChannel
+}
+
+// Timeline type used to hold the notes of every track ordered by start time in
+// seconds, then in ticks. Notes starting together keep the order of their tracks
+type Timeline []TimedNote
+
+// Timeline merges the notes of every track into a single timeline, using the
+// tempo map to convert ticks to seconds. The tracks of a format 2 file are
+// separate sequences, each timed from its own start; use Sequences to keep them
+// apart
+func (f *MidiFile) Timeline() Timeline {
+	var timeline Timeline
+
+	for i := range f.Tracks {
+		tempoMap := f.TrackTempoMap(i)
+
+		for _, note := range f.Tracks[i].Notes {
+			end := note.StartTime + note.Duration
+			timeline = append(timeline, TimedNote{
+				Track:     i,
+				Channel:   note.Channel,
+				Key:       note.Key,
+				Velocity:  note.Velocity,
+				StartTick: note.StartTime,
+				EndTick:   end,
+				Start:     tempoMap.TickToSeconds(note.StartTime),
+				End:       tempoMap.TickToSeconds(end),
+			})
+		}
+	}
+
+	// Sequences of a format 2 file have their own tempo maps, so ticks alone do
+	// not order their notes
+	sort.SliceStable(timeline, func(i, j int) bool {
+		if timeline[i].Start != timeline[j].Start {
+			return timeline[i].Start < timeline[j].Start
+		}
+		return timeline[i].StartTick < timeline[j].StartTick
+	})
+
+	return timeline
+}
+
+// Length returns the time in seconds at which the last note of the timeline ends
+func (t Timeline) Length() float64 {
+	var length float64
+
+	for _, note := range t {
+		if note.End > length {
+			length = note.End
+		}
+	}
+
+	return length
+}
+
+// Between returns the notes starting at or after start and before end, in seconds
+func (t Timeline) Between(start, end float64) Timeline {
+	first := sort.Search(len(t), func(i int) bool { return t[i].Start >= start })
+	last := sort.Search(len(t), func(i int) bool { return t[i].Start >= end })
+
+	if first >= last {
+		return nil
+	}
+
+	return t[first:last]
+}
+
+// Sounding returns the notes that sound at any time after start and before end,
+// in seconds, including notes that started earlier and are still held
+func (t Timeline) Sounding(start, end float64) Timeline {
+	var notes Timeline
+
+	for _, note := range t {
+		if note.Start >= end {
+			break
+		}
+		if note.End > start {
+			notes = append(notes, note)
+		}
+	}
+
+	return notes
+}
+
+// Track returns the notes of the timeline from the given track
+func (t Timeline) Track(track int) Timeline {
+	var notes Timeline
+
+	for _, note := range t {
+		if note.Track == track {
+			notes = append(notes, note)
+		}
+	}
+
+	return notes
+}
@@ -0,0 +1,97 @@
+package midi_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+// keys returns the keys of the notes of a timeline
+func keys(timeline midi.Timeline) []byte {
+	var k []byte
+	for _, note := range timeline {
+		k = append(k, note.Key)
+	}
+	return k
+}
+
+func Test_Timeline(t *testing.T) {
+	// 120 BPM, then 240 BPM from tick 96
+	tempo := "\x00\xff\x51\x03\x07\xa1\x20\x60\xff\x51\x03\x03\xd0\x90"
+	right := "\x00\x90\x3c\x40\x60\x80\x3c\x00\x00\x90\x40\x40\x60\x80\x40\x00"
+	left := "\x00\x91\x43\x40\x60\x91\x45\x40\x30\x81\x45\x00\x30\x81\x43\x00"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(1, 96, tempo, right, left)); err != nil {
+		t.Fatal(err)
+	}
+
+	timeline := f.Timeline()
+	want := []midi.TimedNote{
+		{Track: 1, Channel: 0, Key: 0x3c, Velocity: 0x40, StartTick: 0, EndTick: 96, Start: 0, End: 0.5},
+		{Track: 2, Channel: 1, Key: 0x43, Velocity: 0x40, StartTick: 0, EndTick: 192, Start: 0, End: 0.75},
+		{Track: 1, Channel: 0, Key: 0x40, Velocity: 0x40, StartTick: 96, EndTick: 192, Start: 0.5, End: 0.75},
+		{Track: 2, Channel: 1, Key: 0x45, Velocity: 0x40, StartTick: 96, EndTick: 144, Start: 0.5, End: 0.625},
+	}
+	if len(timeline) != len(want) {
+		t.Fatalf("Timeline() has %d notes; want %d", len(timeline), len(want))
+	}
+	for i, note := range timeline {
+		w := want[i]
+		if note.Track != w.Track || note.Channel != w.Channel || note.Key != w.Key || note.Velocity != w.Velocity ||
+			note.StartTick != w.StartTick || note.EndTick != w.EndTick ||
+			math.Abs(note.Start-w.Start) > 1e-9 || math.Abs(note.End-w.End) > 1e-9 {
+			t.Errorf("note %d = %+v; want %+v", i, note, w)
+		}
+	}
+
+	if d := timeline[3].Duration(); math.Abs(d-0.125) > 1e-9 {
+		t.Errorf("Duration() = %v; want 0.125", d)
+	}
+	if l := timeline.Length(); math.Abs(l-0.75) > 1e-9 {
+		t.Errorf("Length() = %v; want 0.75", l)
+	}
+
+	tests := []struct {
+		name string
+		got  midi.Timeline
+		want string
+	}{
+		{"Between(0, 0.5)", timeline.Between(0, 0.5), "\x3c\x43"},
+		{"Between(0.5, 0.6)", timeline.Between(0.5, 0.6), "\x40\x45"},
+		{"Between(0.8, 1)", timeline.Between(0.8, 1), ""},
+		{"Sounding(0.6, 0.7)", timeline.Sounding(0.6, 0.7), "\x43\x40\x45"},
+		{"Sounding(0.75, 1)", timeline.Sounding(0.75, 1), ""},
+		{"Track(2)", timeline.Track(2), "\x43\x45"},
+	}
+
+	for _, test := range tests {
+		if got := string(keys(test.got)); got != test.want {
+			t.Errorf("%s = % x; want % x", test.name, got, test.want)
+		}
+	}
+}
+
+func Test_TimelineSequences(t *testing.T) {
+	// At 120 BPM tick 192 is 1 second in, while at 60 BPM ticks 48 and 100 are
+	// 0.5 and about 1.04 seconds in
+	first := "\x00\xff\x51\x03\x07\xa1\x20\x81\x40\x90\x3c\x40\x10\x80\x3c\x00"
+	second := "\x00\xff\x51\x03\x0f\x42\x40\x30\x90\x3e\x40\x10\x80\x3e\x00\x24\x90\x40\x40\x10\x80\x40\x00"
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(smf(2, 96, first, second)); err != nil {
+		t.Fatal(err)
+	}
+
+	timeline := f.Timeline()
+	if got := string(keys(timeline)); got != "\x3e\x3c\x40" {
+		t.Errorf("Timeline() keys = % x; want 3e 3c 40", got)
+	}
+	if got := string(keys(timeline.Between(0.9, 1.1))); got != "\x3c\x40" {
+		t.Errorf("Between(0.9, 1.1) = % x; want 3c 40", got)
+	}
+	if got := string(keys(timeline.Sounding(1.05, 1.1))); got != "\x3c\x40" {
+		t.Errorf("Sounding(1.05, 1.1) = % x; want 3c 40", got)
+	}
+}
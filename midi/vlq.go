@@ -0,0 +1,66 @@
+package midi
+
+import "io"
+
+// MaxVLQ is the largest value a MIDI variable-length quantity can hold
+const MaxVLQ = 0x0FFFFFFF
+
+// AppendVLQ appends the variable-length encoding of a value to the slice. Values
+// above MaxVLQ are truncated to 28 bits
+func AppendVLQ(b []byte, v uint32) []byte {
+	v &= MaxVLQ
+
+	// Find the number of seven bit groups needed
+	n := 1
+	for v>>(7*uint(n)) != 0 {
+		n++
+	}
+
+	// Write the groups from the most significant, setting the continuation bit on all but the last
+	for i := n - 1; i > 0; i-- {
+		b = append(b, byte(v>>(7*uint(i)))&0x7F|0x80)
+	}
+
+	return append(b, byte(v)&0x7F)
+}
+
+// DecodeVLQ decodes a variable-length quantity from the start of the slice. It
+// returns the value and the number of bytes it used
+func DecodeVLQ(b []byte) (uint32, int, error) {
+	var v uint32
+
+	for i := 0; i < 4; i++ {
+		if i >= len(b) {
+			return 0, i, io.ErrUnexpectedEOF
+		}
+
+		v = v<<7 | uint32(b[i]&0x7F)
+		if b[i]&0x80 == 0 {
+			return v, i + 1, nil
+		}
+	}
+
+	return 0, 4, ErrBadVLQ
+}
+
+// ReadVLQ reads a variable-length quantity from the reader
+func ReadVLQ(r io.ByteReader) (uint32, error) {
+	var v uint32
+
+	for i := 0; i < 4; i++ {
+		c, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && i > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+
+		v = v<<7 | uint32(c&0x7F)
+		if c&0x80 == 0 {
+			return v, nil
+		}
+	}
+
+	return 0, ErrBadVLQ
+}
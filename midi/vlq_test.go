@@ -0,0 +1,113 @@
+package midi_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+func Test_VLQKnownValues(t *testing.T) {
+	tests := []struct {
+		value   uint32
+		encoded []byte
+	}{
+		{0x00000000, []byte{0x00}},
+		{0x00000040, []byte{0x40}},
+		{0x0000007F, []byte{0x7F}},
+		{0x00000080, []byte{0x81, 0x00}},
+		{0x000001E0, []byte{0x83, 0x60}},
+		{0x00002000, []byte{0xC0, 0x00}},
+		{0x00003FFF, []byte{0xFF, 0x7F}},
+		{0x00004000, []byte{0x81, 0x80, 0x00}},
+		{0x001FFFFF, []byte{0xFF, 0xFF, 0x7F}},
+		{0x00200000, []byte{0x81, 0x80, 0x80, 0x00}},
+		{0x08000000, []byte{0xC0, 0x80, 0x80, 0x00}},
+		{0x0FFFFFFF, []byte{0xFF, 0xFF, 0xFF, 0x7F}},
+	}
+
+	for _, test := range tests {
+		if b := midi.AppendVLQ(nil, test.value); !bytes.Equal(b, test.encoded) {
+			t.Errorf("AppendVLQ(%#x) = % x; want % x", test.value, b, test.encoded)
+		}
+
+		v, n, err := midi.DecodeVLQ(append(test.encoded, 0xAA))
+		if err != nil || v != test.value || n != len(test.encoded) {
+			t.Errorf("DecodeVLQ(% x) = %#x, %d, %v; want %#x, %d", test.encoded, v, n, err, test.value, len(test.encoded))
+		}
+
+		v, err = midi.ReadVLQ(bytes.NewReader(test.encoded))
+		if err != nil || v != test.value {
+			t.Errorf("ReadVLQ(% x) = %#x, %v; want %#x", test.encoded, v, err, test.value)
+		}
+	}
+}
+
+func Test_VLQRoundTrip(t *testing.T) {
+	// Every value in the 28 bit range, or a sample of them in short mode
+	step := uint32(1)
+	if testing.Short() {
+		step = 4099
+	}
+
+	var b []byte
+	for v := uint32(0); v <= midi.MaxVLQ; v += step {
+		b = midi.AppendVLQ(b[:0], v)
+
+		d, n, err := midi.DecodeVLQ(b)
+		if err != nil || d != v || n != len(b) {
+			t.Fatalf("round trip of %#x gave %#x, %d, %v", v, d, n, err)
+		}
+	}
+}
+
+func Test_VLQErrors(t *testing.T) {
+	if _, _, err := midi.DecodeVLQ([]byte{0x81, 0x80}); err != io.ErrUnexpectedEOF {
+		t.Errorf("DecodeVLQ() of truncated value error = %v; want %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, _, err := midi.DecodeVLQ([]byte{0x81, 0x80, 0x80, 0x80, 0x00}); err != midi.ErrBadVLQ {
+		t.Errorf("DecodeVLQ() of 5 byte value error = %v; want %v", err, midi.ErrBadVLQ)
+	}
+	if _, err := midi.ReadVLQ(bytes.NewReader([]byte{0x81})); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadVLQ() of truncated value error = %v; want %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := midi.ReadVLQ(bytes.NewReader(nil)); err != io.EOF {
+		t.Errorf("ReadVLQ() of empty input error = %v; want %v", err, io.EOF)
+	}
+}
+
+func Test_VLQDeltaTimes(t *testing.T) {
+	var f midi.MidiFile
+	if _, err := f.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The right hand opens after 0x8f 0x00 (1920) ticks with a note lasting 0x83 0x60 (480) ticks
+	note := f.Tracks[1].Notes[0]
+	if note.Key != 64 || note.StartTime != 1920 || note.Duration != 480 {
+		t.Errorf("first note = %+v; want key 64 at 1920 lasting 480", note)
+	}
+
+	// Delta times of two, three and four bytes
+	track := "\x00\x90\x40\x40\x81\x00\x80\x40\x00" +
+		"\x00\x90\x41\x40\x81\x80\x00\x80\x41\x00" +
+		"\x00\x90\x42\x40\x81\x80\x80\x00\x80\x42\x00"
+	if _, err := f.ParseBytes(smf(1, 96, track)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []midi.MidiNote{
+		{Key: 0x40, Velocity: 0x40, StartTime: 0, Duration: 0x80},
+		{Key: 0x41, Velocity: 0x40, StartTime: 0x80, Duration: 0x4000},
+		{Key: 0x42, Velocity: 0x40, StartTime: 0x4080, Duration: 0x200000},
+	}
+	if len(f.Tracks[0].Notes) != len(want) {
+		t.Fatalf("parsed %d notes; want %d", len(f.Tracks[0].Notes), len(want))
+	}
+	for i, note := range f.Tracks[0].Notes {
+		if note != want[i] {
+			t.Errorf("note %d = %+v; want %+v", i, note, want[i])
+		}
+	}
+}
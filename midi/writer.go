@@ -0,0 +1,294 @@
+package midi
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"sort"
+)
+
+// trackItem type used to order the events of a track while writing it
+type trackItem struct {
+	tick  int32
+	meta  *MetaEvent
+	sysex *SysExEvent
+	event *MidiEvent
+}
+
+// WriteFile writes the file as a Standard MIDI File to the given path
+func (f *MidiFile) WriteFile(outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Write encodes the file as a Standard MIDI File. A format 2 file keeps its
+// format, a file with a single track is written as format 0, and any other as
+// format 1. If the tempo map no longer matches the tempo meta events of the
+// tracks, the tempo events are replaced by the tempo map's changes in the first
+// track. In a format 2 file only the first sequence's tempo events are replaced.
+// Edits to a track's notes are written by rebuilding its NoteOn and NoteOff
+// events, and edits to the fields of a meta event replace its raw data
+func (f *MidiFile) Write(w io.Writer) error {
+	if len(f.Tracks) > 0xFFFF {
+		return errors.New("midi: too many tracks to write")
+	}
+	if !f.TimeDivision.valid() {
+		return errors.New("midi: invalid time division")
+	}
+
+	format := FormatMultiTrack
+	if f.Format == FormatMultiSequence {
+		format = FormatMultiSequence
+	} else if len(f.Tracks) == 1 {
+		format = FormatSingleTrack
+	}
+
+	// Write the header chunk
+	out := bufio.NewWriter(w)
+	division := f.TimeDivision.encode()
+	out.WriteString("MThd")
+	out.Write([]byte{0, 0, 0, 6, 0, byte(format), byte(len(f.Tracks) >> 8), byte(len(f.Tracks)), division[0], division[1]})
+
+	// Write the track chunks
+	tempoEvents := f.MetaOfType(MetaSetTempo)
+	if format == FormatMultiSequence && len(f.Tracks) > 0 {
+		tempoEvents = f.Tracks[0].MetaOfType(MetaSetTempo)
+	}
+
+	replaceTempo := !sameTempoChanges(newTempoMap(f.TimeDivision, tempoEvents).Changes, f.TempoMap.Changes)
+	for i := range f.Tracks {
+		meta := f.Tracks[i].Meta
+		if replaceTempo && (format != FormatMultiSequence || i == 0) {
+			meta = f.tempoMeta(i)
+		}
+
+		data := f.encodeTrack(&f.Tracks[i], meta)
+		out.WriteString("MTrk")
+		out.Write([]byte{byte(len(data) >> 24), byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))})
+		out.Write(data)
+	}
+
+	return out.Flush()
+}
+
+// sameTempoChanges returns true if the two lists hold the same tempo changes
+func sameTempoChanges(a, b []TempoChange) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tempoMeta returns the meta events of a track with its tempo events replaced by
+// the tempo map. The tempo map is only written to the first track
+func (f *MidiFile) tempoMeta(trackIndex int) []MetaEvent {
+	var meta []MetaEvent
+
+	for _, event := range f.Tracks[trackIndex].Meta {
+		if event.Type != MetaSetTempo {
+			meta = append(meta, event)
+		}
+	}
+
+	if trackIndex == 0 {
+		for _, change := range f.TempoMap.Changes {
+			meta = append(meta, MetaEvent{Type: MetaSetTempo, Tick: change.Tick, Tempo: change.Tempo})
+		}
+	}
+
+	return meta
+}
+
+// encodeTrack encodes the events of a track, ending with an End of Track event.
+// Meta events come first at the same tick, followed by system exclusive events and
+// then channel events
+func (f *MidiFile) encodeTrack(t *MidiTrack, meta []MetaEvent) []byte {
+	var items []trackItem
+
+	// Collect every event with its absolute tick
+	for i := range meta {
+		items = append(items, trackItem{tick: meta[i].Tick, meta: &meta[i]})
+	}
+
+	for i := range t.SysEx {
+		items = append(items, trackItem{tick: t.SysEx[i].Tick, sysex: &t.SysEx[i]})
+	}
+
+	events := f.syncedEvents(t)
+	for i := range events {
+		items = append(items, trackItem{tick: events[i].tick, event: &events[i].event})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].tick < items[j].tick
+	})
+
+	// Write the events
+	var b []byte
+	var lastTick int32
+	var previousStatus byte
+
+	for _, item := range items {
+		b = AppendVLQ(b, uint32(item.tick-lastTick))
+		lastTick = item.tick
+
+		if item.meta != nil {
+			// Meta events cancel running status
+			data := item.meta.encode()
+			b = append(b, 0xFF, item.meta.Type)
+			b = AppendVLQ(b, uint32(len(data)))
+			b = append(b, data...)
+			previousStatus = 0
+			continue
+		}
+
+		if item.sysex != nil {
+			// System exclusive events cancel running status
+			b = append(b, item.sysex.Status())
+			b = AppendVLQ(b, uint32(len(item.sysex.Data)))
+			b = append(b, item.sysex.Data...)
+			previousStatus = 0
+			continue
+		}
+
+		event := *item.event
+		status := event.Status()
+
+		// With running status, a silent NoteOff can reuse a NoteOn status
+		if f.RunningStatus && event.Kind == KindNoteOff && event.Data[1] == 0 && previousStatus == VoiceNoteOn|event.Channel {
+			status = previousStatus
+		}
+
+		if !f.RunningStatus || status != previousStatus {
+			b = append(b, status)
+		}
+		previousStatus = status
+
+		b = append(b, event.Data[:event.Kind.dataLength()]...)
+	}
+
+	// End the track at its recorded end, or after its last event if that is later
+	end := t.EndTick
+	if end < lastTick {
+		end = lastTick
+	}
+	b = AppendVLQ(b, uint32(end-lastTick))
+	return append(b, 0xFF, MetaEndOfTrack, 0x00)
+}
+
+// timedEvent type used to hold a channel event with its absolute tick
+type timedEvent struct {
+	tick  int32
+	event MidiEvent
+}
+
+// sameNotes returns true if the two lists hold the same notes
+func sameNotes(a, b []MidiNote) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// syncedEvents returns the channel events of a track with their absolute ticks.
+// If the notes no longer match the ones the events pair into, the NoteOn and
+// NoteOff events are rebuilt from the notes. A track without notes keeps its
+// events, so tracks built from events alone are written as they are
+func (f *MidiFile) syncedEvents(t *MidiTrack) []timedEvent {
+	var events []timedEvent
+	var wallTime int32
+	for _, event := range t.Events {
+		wallTime += event.DeltaTick
+		events = append(events, timedEvent{wallTime, event})
+	}
+
+	if len(t.Notes) == 0 && len(t.Unclosed) == 0 {
+		return events
+	}
+
+	// Pair the events the way parsing does
+	paired := MidiTrack{Events: t.Events, EndTick: t.EndTick}
+	paired.pairNotes(f.Pairing)
+	if f.Lenient && len(paired.Unclosed) > 0 {
+		paired.Notes = append(paired.Notes, paired.Unclosed...)
+		paired.Unclosed = nil
+		sort.SliceStable(paired.Notes, func(i, j int) bool {
+			return paired.Notes[i].StartTime < paired.Notes[j].StartTime
+		})
+	}
+	if f.Pedals {
+		paired.applyPedals()
+	}
+
+	if sameNotes(paired.Notes, t.Notes) && sameNotes(paired.Unclosed, t.Unclosed) {
+		return events
+	}
+
+	// Replace the note events. At the same tick, notes are released before the
+	// other events and struck after them
+	var rebuilt []timedEvent
+	var ranks []int
+	for _, e := range events {
+		if e.event.Kind != KindNoteOn && e.event.Kind != KindNoteOff {
+			rebuilt = append(rebuilt, e)
+			ranks = append(ranks, 1)
+		}
+	}
+
+	for _, note := range t.Notes {
+		rebuilt = append(rebuilt,
+			timedEvent{note.StartTime, NewMidiEvent(0, NoteOn{note.Channel, note.Key, note.Velocity})},
+			timedEvent{note.StartTime + note.Duration, NewMidiEvent(0, NoteOff{note.Channel, note.Key, 0})},
+		)
+		ranks = append(ranks, 2, 0)
+	}
+
+	// Unclosed notes are never released
+	for _, note := range t.Unclosed {
+		rebuilt = append(rebuilt, timedEvent{note.StartTime, NewMidiEvent(0, NoteOn{note.Channel, note.Key, note.Velocity})})
+		ranks = append(ranks, 2)
+	}
+
+	order := make([]int, len(rebuilt))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if rebuilt[a].tick != rebuilt[b].tick {
+			return rebuilt[a].tick < rebuilt[b].tick
+		}
+		return ranks[a] < ranks[b]
+	})
+
+	sorted := make([]timedEvent, len(rebuilt))
+	for i, index := range order {
+		sorted[i] = rebuilt[index]
+	}
+
+	return sorted
+}
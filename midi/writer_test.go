@@ -0,0 +1,217 @@
+package midi_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ethanbaker/midi-to-musicbox/midi"
+)
+
+// roundTrip writes the file and parses the result
+func roundTrip(t *testing.T, f *midi.MidiFile) ([]byte, midi.MidiFile) {
+	var b bytes.Buffer
+	if err := f.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed midi.MidiFile
+	if _, err := parsed.ParseBytes(b.Bytes()); err != nil {
+		t.Fatalf("parsing written file: %v", err)
+	}
+
+	return b.Bytes(), parsed
+}
+
+// compareFiles reports every difference between the parsed data of two files
+func compareFiles(t *testing.T, name string, got, want *midi.MidiFile) {
+	if got.TimeDivision != want.TimeDivision {
+		t.Errorf("%s: TimeDivision = %v; want %v", name, got.TimeDivision, want.TimeDivision)
+	}
+	if !reflect.DeepEqual(got.TempoMap, want.TempoMap) {
+		t.Errorf("%s: TempoMap = %+v; want %+v", name, got.TempoMap, want.TempoMap)
+	}
+	if len(got.Tracks) != len(want.Tracks) {
+		t.Fatalf("%s: %d tracks; want %d", name, len(got.Tracks), len(want.Tracks))
+	}
+	for i := range want.Tracks {
+		if !reflect.DeepEqual(got.Tracks[i], want.Tracks[i]) {
+			t.Errorf("%s: track %d differs after a round trip", name, i)
+		}
+	}
+}
+
+func Test_WriteRoundTrip(t *testing.T) {
+	var f midi.MidiFile
+	if _, err := f.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+
+	plain, parsed := roundTrip(t, &f)
+	compareFiles(t, "plain", &parsed, &f)
+	if plain[9] != 1 {
+		t.Errorf("format = %d; want 1", plain[9])
+	}
+
+	// Running status gives a smaller file with the same data
+	f.RunningStatus = true
+	compact, parsed := roundTrip(t, &f)
+	compareFiles(t, "running status", &parsed, &f)
+	if len(compact) >= len(plain) {
+		t.Errorf("running status file is %d bytes; want less than %d", len(compact), len(plain))
+	}
+
+	// Writing to a path gives the same bytes
+	path := filepath.Join(t.TempDir(), "out.mid")
+	if err := f.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	var fromPath midi.MidiFile
+	if _, err := fromPath.Parse(path); err != nil {
+		t.Fatal(err)
+	}
+	compareFiles(t, "file", &fromPath, &f)
+}
+
+func Test_WriteFormat0(t *testing.T) {
+	f := midi.MidiFile{TimeDivision: midi.TimeDivision{TicksPerQuarter: 96}}
+	f.Tracks = []midi.MidiTrack{{
+		Meta: []midi.MetaEvent{
+			{Type: midi.MetaTrackName, Text: "Melody"},
+			{Type: midi.MetaTimeSignature, Tick: 0, TimeSignature: &midi.TimeSignature{Numerator: 3, Denominator: 4, ClocksPerClick: 24, ThirtySecondsPerQuarter: 8}},
+			{Type: midi.MetaLyrics, Tick: 96, Text: "la"},
+		},
+		Events: []midi.MidiEvent{
+			midi.NewMidiEvent(0, midi.ProgramChange{Channel: 0, Program: 10}),
+			midi.NewMidiEvent(0, midi.NoteOn{Channel: 0, Key: 60, Velocity: 100}),
+			midi.NewMidiEvent(96, midi.NoteOff{Channel: 0, Key: 60}),
+			midi.NewMidiEvent(0, midi.NoteOn{Channel: 0, Key: 62, Velocity: 100}),
+			midi.NewMidiEvent(200, midi.NoteOff{Channel: 0, Key: 62}),
+		},
+	}}
+	f.TempoMap = midi.TempoMap{TimeDivision: f.TimeDivision, Changes: []midi.TempoChange{{Tick: 0, Tempo: 400000}, {Tick: 150, Tempo: 600000}}}
+	f.RunningStatus = true
+
+	b, parsed := roundTrip(t, &f)
+	if b[9] != 0 {
+		t.Errorf("format = %d; want 0", b[9])
+	}
+
+	if !reflect.DeepEqual(parsed.TempoMap, f.TempoMap) {
+		t.Errorf("TempoMap = %+v; want %+v", parsed.TempoMap, f.TempoMap)
+	}
+	track := parsed.Tracks[0]
+	if track.Name != "Melody" {
+		t.Errorf("track name = %q; want Melody", track.Name)
+	}
+	if !reflect.DeepEqual(track.Events, f.Tracks[0].Events) {
+		t.Errorf("events = %+v; want %+v", track.Events, f.Tracks[0].Events)
+	}
+	if ts := track.MetaOfType(midi.MetaTimeSignature); len(ts) != 1 || *ts[0].TimeSignature != *f.Tracks[0].Meta[1].TimeSignature {
+		t.Errorf("time signature = %+v", ts)
+	}
+	if lyrics := track.MetaOfType(midi.MetaLyrics); len(lyrics) != 1 || lyrics[0].Text != "la" || lyrics[0].Tick != 96 {
+		t.Errorf("lyrics = %+v", lyrics)
+	}
+	if len(track.Notes) != 2 || track.Notes[1].Duration != 200 {
+		t.Errorf("notes = %+v", track.Notes)
+	}
+}
+
+// sortedNotes returns a copy of the notes ordered by start, channel and key
+func sortedNotes(notes []midi.MidiNote) []midi.MidiNote {
+	sorted := append([]midi.MidiNote(nil), notes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.StartTime != b.StartTime {
+			return a.StartTime < b.StartTime
+		}
+		if a.Channel != b.Channel {
+			return a.Channel < b.Channel
+		}
+		return a.Key < b.Key
+	})
+	return sorted
+}
+
+func Test_WriteEdits(t *testing.T) {
+	var f midi.MidiFile
+	if _, err := f.Parse("./testing/midi.mid"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Move the first note, lengthen the second, drop the third and add one
+	track := &f.Tracks[1]
+	track.Notes[0].Key = 72
+	track.Notes[1].Duration += 100
+	track.Notes = append(track.Notes[:2], track.Notes[3:]...)
+	track.Notes = append(track.Notes, midi.MidiNote{Channel: 0, Key: 84, Velocity: 90, StartTime: 10, Duration: 50})
+
+	// Rename the track and change the tempo through the decoded fields
+	for i := range track.Meta {
+		if track.Meta[i].Type == midi.MetaTrackName {
+			track.Meta[i].Text = "Melody"
+		}
+	}
+	for i := range f.Tracks[0].Meta {
+		if f.Tracks[0].Meta[i].Type == midi.MetaSetTempo {
+			f.Tracks[0].Meta[i].Tempo = 400000
+		}
+	}
+	f.TempoMap.Changes[0].Tempo = 400000
+
+	_, parsed := roundTrip(t, &f)
+	if got, want := sortedNotes(parsed.Tracks[1].Notes), sortedNotes(track.Notes); !reflect.DeepEqual(got, want) {
+		t.Errorf("notes = %+v; want %+v", got, want)
+	}
+	if parsed.Tracks[1].Name != "Melody" {
+		t.Errorf("track name = %q; want Melody", parsed.Tracks[1].Name)
+	}
+	if parsed.Tempo != 400000 {
+		t.Errorf("Tempo = %d; want 400000", parsed.Tempo)
+	}
+
+	// The untouched track is written as it was
+	compareFiles(t, "untouched", &midi.MidiFile{Tracks: parsed.Tracks[2:]}, &midi.MidiFile{Tracks: f.Tracks[2:]})
+}
+
+func Test_WriteEndOfTrack(t *testing.T) {
+	// A note followed by 960 ticks of silence before End of Track
+	data := smf(0, 96, "\x00\x90\x3c\x40\x60\x80\x3c\x00\x87\x40\xff\x2f\x00")
+
+	var f midi.MidiFile
+	if _, err := f.ParseBytes(data); err != nil {
+		t.Fatal(err)
+	}
+
+	b, parsed := roundTrip(t, &f)
+	if !bytes.HasSuffix(b, []byte("\x80\x3c\x00\x87\x40\xff\x2f\x00")) {
+		t.Errorf("track ends with % x; want the silence kept", b[len(b)-8:])
+	}
+	if parsed.Tracks[0].EndTick != 1056 {
+		t.Errorf("EndTick = %d; want 1056", parsed.Tracks[0].EndTick)
+	}
+
+	// A note moved past the end lengthens the track
+	f.Tracks[0].Notes[0].StartTime = 2000
+	if _, parsed = roundTrip(t, &f); parsed.Tracks[0].EndTick != 2096 {
+		t.Errorf("EndTick = %d; want 2096", parsed.Tracks[0].EndTick)
+	}
+}
+
+func Test_WriteTimeDivision(t *testing.T) {
+	tests := []midi.TimeDivision{
+		{},
+		{FramesPerSecond: 23, TicksPerFrame: 40},
+		{FramesPerSecond: 25},
+	}
+
+	for _, division := range tests {
+		f := midi.MidiFile{TimeDivision: division}
+		if err := f.Write(&bytes.Buffer{}); err == nil {
+			t.Errorf("Write() with division %+v succeeded; want an error", division)
+		}
+	}
+}